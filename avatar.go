@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// AvatarConfig limits uploaded avatars, they are kept in mediaStore under KeyPrefix.
+// MaxBytes caps the whole upload request, MaxPixels guards against
+// small files that decode into huge images. Every avatar is also stored
+// as square thumbnails of each of Sizes. Unreferenced avatars older than
+// GCGracePeriod are collected every GCInterval.
+type AvatarConfig struct {
+	KeyPrefix     string
+	MaxBytes      int64
+	MaxPixels     int
+	Sizes         []int
+	GCInterval    time.Duration
+	GCGracePeriod time.Duration
+}
+
+var avatarConfig = AvatarConfig{
+	KeyPrefix:     "avatar/",
+	MaxBytes:      2 << 20,
+	MaxPixels:     4096 * 4096,
+	Sizes:         []int{64, 128, 256},
+	GCInterval:    time.Hour,
+	GCGracePeriod: time.Hour,
+}
+
+var (
+	ErrAvatarTooLarge = errors.New("avatar is too large")
+	ErrAvatarFormat   = errors.New("avatar must be png, jpeg, webp or gif")
+	ErrAvatarBroken   = errors.New("avatar can't be decoded")
+)
+
+var avatarFormats = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/webp": true,
+	"image/gif":  true,
+}
+
+// avatarURL is URL of user avatar, generated one if nothing was uploaded.
+// Old users keep the whole path in avatar, only its base name counts.
+func avatarURL(user *User) string {
+	if user.avatar == "" {
+		return defaultAvatarURL(user.uuid, 0)
+	}
+	return mediaStore.URL(avatarConfig.KeyPrefix + filepath.Base(user.avatar))
+}
+
+// avatarVariant is the file name of avatar thumbnail of given size
+func avatarVariant(avatar string, size int) string {
+	name := filepath.Base(avatar)
+	extension := filepath.Ext(name)
+	return strings.TrimSuffix(name, extension) + "_" + strconv.Itoa(size) + extension
+}
+
+// avatarURLs maps thumbnail size to its URL. Avatars uploaded before
+// thumbnails existed were stored by path and have none.
+func avatarURLs(user *User) map[string]string {
+	if user.avatar != "" && filepath.Base(user.avatar) != user.avatar {
+		return nil
+	}
+	urls := make(map[string]string, len(avatarConfig.Sizes))
+	for _, size := range avatarConfig.Sizes {
+		if user.avatar == "" {
+			urls[strconv.Itoa(size)] = defaultAvatarURL(user.uuid, size)
+		} else {
+			urls[strconv.Itoa(size)] = mediaStore.URL(avatarConfig.KeyPrefix + avatarVariant(user.avatar, size))
+		}
+	}
+	return urls
+}
+
+// DecodeAvatar checks that data is an image of allowed format and size
+// and decodes it. Only pixels survive, so metadata is gone after re-encoding.
+func DecodeAvatar(data []byte) (image.Image, string, error) {
+	// DetectContentType looks at the content only, never at names the client sent
+	if !avatarFormats[http.DetectContentType(data)] {
+		return nil, "", ErrAvatarFormat
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrAvatarBroken
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, "", ErrAvatarBroken
+	}
+	if config.Width > avatarConfig.MaxPixels/config.Height {
+		return nil, "", ErrAvatarTooLarge
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrAvatarBroken
+	}
+	return img, format, nil
+}
+
+// EncodeAvatar writes photos as jpeg and everything else as png,
+// it returns encoded image and file extension for it
+func EncodeAvatar(img image.Image, format string) ([]byte, string, error) {
+	buffer := &bytes.Buffer{}
+	if format == "jpeg" {
+		err := jpeg.Encode(buffer, img, &jpeg.Options{Quality: 90})
+		return buffer.Bytes(), ".jpg", err
+	}
+	err := png.Encode(buffer, img)
+	return buffer.Bytes(), ".png", err
+}
+
+// SquareCrop returns the biggest square in the middle of rect
+func SquareCrop(rect image.Rectangle) image.Rectangle {
+	side := rect.Dx()
+	if rect.Dy() < side {
+		side = rect.Dy()
+	}
+	min := rect.Min.Add(image.Pt((rect.Dx()-side)/2, (rect.Dy()-side)/2))
+	return image.Rectangle{min, min.Add(image.Pt(side, side))}
+}
+
+// ResizeAvatar scales square part of img to size x size
+func ResizeAvatar(img image.Image, square image.Rectangle, size int) image.Image {
+	thumbnail := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, square, draw.Src, nil)
+	return thumbnail
+}
+
+// SetAvatar stores uploaded image with its thumbnails as the new avatar
+// of user and releases the previous one. Avatars are named by hash of
+// their content, so users with the same picture share its blobs. Thumbnails are cut from crop,
+// which is relative to the top left corner of the image, or from the
+// middle of the whole image if crop is nil.
+func SetAvatar(user *User, data []byte, crop *image.Rectangle) (*User, error) {
+	img, format, err := DecodeAvatar(data)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	square := SquareCrop(bounds)
+	if crop != nil {
+		rect := crop.Add(bounds.Min)
+		if rect.Empty() || !rect.In(bounds) {
+			return nil, &ValidationError{[]FieldError{{"crop", "out_of_bounds", "crop must lie inside the image"}}}
+		}
+		square = SquareCrop(rect)
+	}
+
+	encoded, extension, err := EncodeAvatar(img, format)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	hash.Write(encoded)
+	fmt.Fprint(hash, square)
+	name := hex.EncodeToString(hash.Sum(nil)) + extension
+	files := map[string][]byte{name: encoded}
+	for _, size := range avatarConfig.Sizes {
+		files[avatarVariant(name, size)], _, err = EncodeAvatar(ResizeAvatar(img, square, size), format)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upload holds a reference, so nobody removes blobs while they are written
+	avatarRefs.Acquire(name)
+	for fileName, fileData := range files {
+		err = mediaStore.Put(avatarConfig.KeyPrefix+fileName, fileData, mime.TypeByExtension(extension))
+		if err != nil {
+			avatarRefs.Release(name)
+			return nil, err
+		}
+	}
+
+	var previous string
+	user, err = UpdateUser(user.uuid, func(user *User) error {
+		previous = user.avatar
+		user.avatar = name
+		return nil
+	})
+	if err != nil {
+		avatarRefs.Release(name)
+		return nil, err
+	}
+	if previous != "" {
+		avatarRefs.Release(previous)
+	}
+	return user, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"log"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AvatarRefs counts references to every avatar: users having it
+// and uploads of it still in progress. Blobs are content addressed and
+// may be shared with other servers, which count their own references,
+// so blobs are removed only by CollectAvatarGarbage.
+type AvatarRefs struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+var avatarRefs = NewAvatarRefs(nil)
+
+func NewAvatarRefs(users []User) *AvatarRefs {
+	refs := &AvatarRefs{counts: make(map[string]int)}
+	for _, user := range users {
+		if user.avatar != "" {
+			refs.counts[filepath.Base(user.avatar)]++
+		}
+	}
+	return refs
+}
+
+func (refs *AvatarRefs) Acquire(avatar string) {
+	refs.mu.Lock()
+	defer refs.mu.Unlock()
+
+	refs.counts[filepath.Base(avatar)]++
+}
+
+// Release drops a reference to avatar, its blobs stay until garbage collection
+func (refs *AvatarRefs) Release(avatar string) {
+	refs.mu.Lock()
+	defer refs.mu.Unlock()
+
+	name := filepath.Base(avatar)
+	refs.counts[name]--
+	if refs.counts[name] <= 0 {
+		delete(refs.counts, name)
+	}
+}
+
+func (refs *AvatarRefs) Count(avatar string) int {
+	refs.mu.Lock()
+	defer refs.mu.Unlock()
+
+	return refs.counts[filepath.Base(avatar)]
+}
+
+// avatarOfBlob returns name of avatar the blob belongs to,
+// for thumbnails it is the name of the original
+func avatarOfBlob(key string) string {
+	name := path.Base(key)
+	extension := path.Ext(name)
+	stem := strings.TrimSuffix(name, extension)
+	if i := strings.LastIndex(stem, "_"); i >= 0 {
+		if _, err := strconv.Atoi(stem[i+1:]); err == nil {
+			stem = stem[:i]
+		}
+	}
+	return stem + extension
+}
+
+// CollectAvatarGarbage removes avatar blobs nobody references, returns how many were removed.
+// Blobs of replaced avatars, crashed uploads and other servers all end up here.
+// Blobs younger than GCGracePeriod are kept, they may belong to uploads
+// in progress elsewhere.
+func CollectAvatarGarbage(now time.Time) (int, error) {
+	blobs, err := mediaStore.List(avatarConfig.KeyPrefix)
+	if err != nil {
+		return 0, err
+	}
+	userSlice, err := userStore.All()
+	if err != nil {
+		return 0, err
+	}
+	referenced := make(map[string]bool, len(userSlice))
+	for _, user := range userSlice {
+		referenced[filepath.Base(user.avatar)] = true
+	}
+
+	count := 0
+	for _, blob := range blobs {
+		avatar := avatarOfBlob(blob.Key)
+		if referenced[avatar] || avatarRefs.Count(avatar) > 0 || now.Sub(blob.Modified) < avatarConfig.GCGracePeriod {
+			continue
+		}
+		err = mediaStore.Delete(blob.Key)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// StartAvatarGC collects avatar garbage every interval until stop is called
+func StartAvatarGC(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				count, err := CollectAvatarGarbage(timeNow())
+				if err != nil {
+					log.Println("avatar gc:", err)
+				}
+				if count != 0 {
+					log.Println("avatar gc: removed", count, "blobs")
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
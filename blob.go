@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BlobStore keeps media files by slash separated keys like "avatar/name.png".
+// Get returns ErrNotFound for unknown keys, Delete ignores them.
+// List returns every blob with key starting with prefix.
+// URL is where clients download the blob from.
+type BlobStore interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+	List(prefix string) ([]BlobInfo, error)
+	URL(key string) string
+}
+
+type BlobInfo struct {
+	Key      string
+	Modified time.Time
+}
+
+var ErrBadBlobKey = errors.New("bad blob key")
+
+// mediaStore keeps avatars, it is replaced in main and tests
+var mediaStore BlobStore = NewLocalBlobStore("media", "/media/")
+
+func checkBlobKey(key string) error {
+	if key == "" || path.Clean(key) != key || strings.HasPrefix(key, "/") || strings.HasPrefix(key, "../") || key == ".." {
+		return ErrBadBlobKey
+	}
+	return nil
+}
+
+// LocalBlobStore keeps blobs as files under Dir, the router serves them at URLPrefix
+type LocalBlobStore struct {
+	Dir       string
+	URLPrefix string
+}
+
+func NewLocalBlobStore(dir string, urlPrefix string) *LocalBlobStore {
+	return &LocalBlobStore{Dir: dir, URLPrefix: urlPrefix}
+}
+
+func (store *LocalBlobStore) path(key string) (string, error) {
+	err := checkBlobKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(store.Dir, filepath.FromSlash(key)), nil
+}
+
+func (store *LocalBlobStore) Put(key string, data []byte, contentType string) error {
+	filename, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+}
+
+func (store *LocalBlobStore) Get(key string) ([]byte, error) {
+	filename, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (store *LocalBlobStore) Delete(key string) error {
+	filename, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (store *LocalBlobStore) List(prefix string) ([]BlobInfo, error) {
+	blobs := make([]BlobInfo, 0)
+	err := filepath.Walk(store.Dir, func(filename string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// temporary files of writeFileAtomic start with a dot
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		key, err := filepath.Rel(store.Dir, filename)
+		if err != nil {
+			return err
+		}
+		key = filepath.ToSlash(key)
+		if strings.HasPrefix(key, prefix) {
+			blobs = append(blobs, BlobInfo{key, info.ModTime()})
+		}
+		return nil
+	})
+	return blobs, err
+}
+
+func (store *LocalBlobStore) URL(key string) string {
+	return store.URLPrefix + key
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it,
+// so nobody ever sees a half written file
+func writeFileAtomic(path string, data []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// S3Config points to a bucket of any S3 compatible storage (AWS, MinIO, ...).
+// Objects are addressed path style: Endpoint/Bucket/key. Clients download
+// them from PublicURL/key, which is Endpoint/Bucket by default,
+// so the bucket has to allow anonymous reads.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string
+}
+
+// S3BlobStore talks to S3 REST API directly, requests are signed with AWS Signature V4
+type S3BlobStore struct {
+	config S3Config
+	client *http.Client
+}
+
+func NewS3BlobStore(config S3Config) *S3BlobStore {
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.PublicURL == "" {
+		config.PublicURL = config.Endpoint + "/" + config.Bucket
+	}
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+	return &S3BlobStore{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func escapeBlobKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func (store *S3BlobStore) do(method string, key string, data []byte, contentType string) (*http.Response, error) {
+	err := checkBlobKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return store.send(method, "/"+escapeBlobKey(key), nil, data, contentType)
+}
+
+// send makes a signed request to path of the bucket
+func (store *S3BlobStore) send(method string, path string, query url.Values, data []byte, contentType string) (*http.Response, error) {
+	request, err := http.NewRequest(method, store.config.Endpoint+"/"+store.config.Bucket+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	request.URL.RawQuery = encodeS3Query(query)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	signS3Request(request, data, store.config, timeNow().UTC())
+	return store.client.Do(request)
+}
+
+func s3Error(method string, key string, response *http.Response) error {
+	body, _ := ioutil.ReadAll(response.Body)
+	return fmt.Errorf("s3 %s %s: %s %s", method, key, response.Status, body)
+}
+
+func (store *S3BlobStore) Put(key string, data []byte, contentType string) error {
+	response, err := store.do("PUT", key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return s3Error("PUT", key, response)
+	}
+	return nil
+}
+
+func (store *S3BlobStore) Get(key string) ([]byte, error) {
+	response, err := store.do("GET", key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, s3Error("GET", key, response)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+func (store *S3BlobStore) Delete(key string) error {
+	response, err := store.do("DELETE", key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return s3Error("DELETE", key, response)
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (store *S3BlobStore) List(prefix string) ([]BlobInfo, error) {
+	blobs := make([]BlobInfo, 0)
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		response, err := store.send("GET", "", query, nil, "")
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode != http.StatusOK {
+			err = s3Error("LIST", prefix, response)
+			response.Body.Close()
+			return nil, err
+		}
+		result := s3ListResult{}
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Contents {
+			blobs = append(blobs, BlobInfo{object.Key, object.LastModified})
+		}
+		if !result.IsTruncated {
+			return blobs, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+func (store *S3BlobStore) URL(key string) string {
+	return store.config.PublicURL + "/" + escapeBlobKey(key)
+}
+
+// encodeS3Query encodes query the way signature wants it: sorted, spaces as %20
+func encodeS3Query(query url.Values) string {
+	return strings.Replace(query.Encode(), "+", "%20", -1)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// signS3Request adds AWS Signature V4 to request, every header already
+// set on request is signed along with host, date and payload hash
+func signS3Request(request *http.Request, payload []byte, config S3Config, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadSum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(payloadSum[:])
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := &strings.Builder{}
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		encodeS3Query(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalSum := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+config.SecretKey), date)
+	key = hmacSHA256(key, config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+config.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
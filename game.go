@@ -0,0 +1,122 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GameRules bound what a single game result may look like
+type GameRules struct {
+	MinDuration       time.Duration
+	MaxDuration       time.Duration
+	MaxScorePerSecond float64
+}
+
+var gameRules = GameRules{
+	MinDuration:       5 * time.Second,
+	MaxDuration:       30 * time.Minute,
+	MaxScorePerSecond: 10,
+}
+
+// timeNow is replaced in tests
+var timeNow = time.Now
+
+// GameError is a rejected game result, Code and Field go to ErrorPayload
+type GameError struct {
+	Code    string
+	Field   string
+	Message string
+}
+
+func (err *GameError) Error() string {
+	return err.Message
+}
+
+var (
+	ErrGameForged   = &GameError{"forged_token", "token", "unknown or expired game token"}
+	ErrGameReplayed = &GameError{"replayed_token", "token", "game result already submitted"}
+	ErrGameTooShort = &GameError{"bad_duration", "duration", "game is too short"}
+	ErrGameTooLong  = &GameError{"bad_duration", "duration", "game is too long"}
+	ErrGameScore    = &GameError{"implausible_score", "score", "implausible score"}
+)
+
+// Game is issued when a player starts playing, its token has to be
+// presented with the result
+type Game struct {
+	token    string
+	user     uint32
+	started  time.Time
+	finished bool
+}
+
+type gameRegistry struct {
+	mu        sync.Mutex
+	games     map[string]*Game
+	lastSweep time.Time
+}
+
+var games = gameRegistry{games: make(map[string]*Game)}
+
+// StartGame issues a new game token for user
+func StartGame(user *User) *Game {
+	game := &Game{
+		token:   uuid.New().String(),
+		user:    user.uuid,
+		started: timeNow(),
+	}
+
+	games.mu.Lock()
+	defer games.mu.Unlock()
+
+	games.sweep(game.started)
+	games.games[game.token] = game
+	return game
+}
+
+// drop games nobody can submit anymore
+func (registry *gameRegistry) sweep(now time.Time) {
+	if now.Sub(registry.lastSweep) < gameRules.MaxDuration {
+		return
+	}
+	for token, game := range registry.games {
+		if now.Sub(game.started) > gameRules.MaxDuration {
+			delete(registry.games, token)
+		}
+	}
+	registry.lastSweep = now
+}
+
+// FinishGame validates result of the game and adds it to user score.
+// Errors about the result itself are *GameError.
+func FinishGame(user *User, token string, score int) (*User, error) {
+	now := timeNow()
+
+	games.mu.Lock()
+	game, ok := games.games[token]
+	if !ok || game.user != user.uuid {
+		games.mu.Unlock()
+		return nil, ErrGameForged
+	}
+	if game.finished {
+		games.mu.Unlock()
+		return nil, ErrGameReplayed
+	}
+	// token is burned even if the result is rejected
+	game.finished = true
+	games.mu.Unlock()
+
+	duration := now.Sub(game.started)
+	if duration < gameRules.MinDuration {
+		return nil, ErrGameTooShort
+	}
+	if duration > gameRules.MaxDuration {
+		return nil, ErrGameTooLong
+	}
+	if score < 0 || float64(score) > gameRules.MaxScorePerSecond*duration.Seconds() {
+		return nil, ErrGameScore
+	}
+
+	return AddScore(user.uuid, score, game.token, ScoreSourceGame)
+}
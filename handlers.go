@@ -2,24 +2,28 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"image"
 	"io/ioutil"
+	"log"
+	"math"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
+// genericAuthErrors hides whether login or password was wrong
+var genericAuthErrors = false
+
 func HandleLogin(w http.ResponseWriter, r *http.Request, session *Session) {
 	userData := &UsrRequest{}
 
 	err := getRequest(userData, r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, "log", err)
 		return
 	}
 
@@ -27,47 +31,53 @@ func HandleLogin(w http.ResponseWriter, r *http.Request, session *Session) {
 		Type: "log",
 	}
 
+	address := remoteAddress(r)
 	if session.user != nil {
 		response.Status = "success"
+	} else if retryAfter := loginThrottle.Attempt(userData.Login, address, timeNow()); retryAfter > 0 {
+		writeError(w, response.Type, &RetryLaterError{retryAfter})
+		return
 	} else {
 		user, err := Auth(userData.Login, userData.Password)
-		if err != nil {
-			wrong := err.Error()
-			response.Status = "error"
-			response.Payload = ErrorPayload{
-				Message: "incorrect " + wrong,
-				Field:   wrong,
+		if err == ErrBadLogin || err == ErrBadPassword {
+			loginThrottle.Fail(userData.Login, address, timeNow())
+			if genericAuthErrors {
+				err = ErrBadCredentials
 			}
-		} else {
-			session.user = user
-			response.Status = "success"
+		} else if err != nil {
+			loginThrottle.Release(userData.Login, address)
+		}
+		if err != nil {
+			writeError(w, response.Type, err)
+			return
 		}
 
-		if response.Status == "success" {
-			response.Payload = UserDataPayload{
-				Login:      user.login,
-				Email:      user.email,
-				Name:       user.name,
-				AvatarPath: user.avatar,
-				Score:      user.score,
-			}
+		loginThrottle.Succeed(userData.Login, address)
+		session.user = user
+		err = renewSession(w, session)
+		if err != nil {
+			writeError(w, response.Type, err)
+			return
 		}
+		response.Status = "success"
+		response.Payload = userPayload(user)
 	}
 
-	byteResponse, _ := response.MarshalJSON()
-	w.Write(byteResponse)
+	writeResponse(w, response)
 }
 
 // HandleRegister handle registration api request
 // request must contain post form:
-// 	login, password, email, name
+//
+//	login, password, email, name
+//
 // Writes status json to response
 func HandleRegister(w http.ResponseWriter, r *http.Request, session *Session) {
 	userData := &UsrRequest{}
 
 	err := getRequest(userData, r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, "reg", err)
 		return
 	}
 
@@ -75,123 +85,216 @@ func HandleRegister(w http.ResponseWriter, r *http.Request, session *Session) {
 		Type: "reg",
 	}
 
+	err = ValidateUsrRequest(userData, true)
+	if err != nil {
+		writeError(w, response.Type, err)
+		return
+	}
+
 	user, err := NewUser(userData.Login, userData.Password, userData.Email, userData.Name)
 	if err == nil {
 		session.user = user
-		response.Status = "success"
-		response.Payload = UserDataPayload{
-			Login:      user.login,
-			Email:      user.email,
-			Name:       user.name,
-			AvatarPath: user.avatar,
-			Score:      user.score,
-		}
-	} else {
-		response.Status = "error"
-		if err.Error() == "user already exists" {
-			response.Payload = ErrorPayload{
-				Message: err.Error(),
-				Field:   "login",
-			}
-		} else {
-			response.Payload = ErrorPayload{
-				Message: "missing " + err.Error(),
-				Field:   err.Error(),
-			}
-		}
+		err = renewSession(w, session)
+	}
+	if err != nil {
+		writeError(w, response.Type, err)
+		return
 	}
 
-	byteResponse, _ := response.MarshalJSON()
-	w.Write(byteResponse)
+	response.Status = "success"
+	response.Payload = userPayload(user)
+
+	writeResponse(w, response)
 }
 
 func HandleAvatarUpload(w http.ResponseWriter, r *http.Request, session *Session) {
-	r.ParseMultipartForm(2 << 21) // 2 mb
+	r.Body = http.MaxBytesReader(w, r.Body, avatarConfig.MaxBytes)
+	err := r.ParseMultipartForm(avatarConfig.MaxBytes)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = ErrAvatarTooLarge
+		} else {
+			err = badRequest(err)
+		}
+		writeError(w, "avatar", err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		writeError(w, "avatar", badRequest(err))
+		return
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		writeError(w, "avatar", badRequest(err))
+		return
+	}
 
-	rFile, handler, err := r.FormFile("avatar")
+	crop, err := getCrop(r)
 	if err != nil {
-		// TODO: write error to response
-		fmt.Println(err)
+		writeError(w, "avatar", err)
 		return
 	}
-	defer rFile.Close()
-	//fmt.Fprintf(w, "%v", handler.Header)
-	filename := filepath.Join(filepath.Join("media", "avatar",
-		uuid.New().String()+handler.Filename))
 
-	wFile, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE, 0666)
+	user, err := SetAvatar(session.user, data, crop)
 	if err != nil {
-		// TODO: write error to response
-		fmt.Println(err)
+		writeError(w, "avatar", err)
 		return
 	}
-	defer wFile.Close()
-	io.Copy(wFile, rFile)
+	session.user = user
 
-	session.user.avatar = filename
-	err = session.user.Save()
-	if err == nil {
-		// TODO: write error to response
+	response := Response{
+		Type:    "avatar",
+		Status:  "success",
+		Payload: userPayload(user),
+	}
+	writeResponse(w, response)
+}
+
+// getCrop reads optional crop rectangle from crop_x, crop_y, crop_width
+// and crop_height form fields, they come all together or not at all
+func getCrop(r *http.Request) (*image.Rectangle, error) {
+	fields := []string{"crop_x", "crop_y", "crop_width", "crop_height"}
+	values := make([]int, 0, len(fields))
+	for _, field := range fields {
+		value := r.FormValue(field)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, &ValidationError{[]FieldError{{field, "not_integer", "must be an integer"}}}
+		}
+		values = append(values, number)
 	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if len(values) != len(fields) {
+		return nil, &ValidationError{[]FieldError{{"crop", "incomplete", "crop needs x, y, width and height"}}}
+	}
+	if values[2] <= 0 || values[3] <= 0 {
+		return nil, &ValidationError{[]FieldError{{"crop", "out_of_bounds", "crop must lie inside the image"}}}
+	}
+	crop := image.Rect(values[0], values[1], values[0]+values[2], values[1]+values[3])
+	return &crop, nil
 }
 
+// HandleGetUsers handles both /api/leaderboard/{page} and /api/leaderboard?cursor=,
+// optionally prefixed with period, count of users per page may be set in query.
+// Past periods are requested with ?date= of any day in them.
 func HandleGetUsers(w http.ResponseWriter, r *http.Request, session *Session) {
 	response := Response{
 		Type: "uslist",
 	}
-	page, err := strconv.Atoi(mux.Vars(r)["page"])
+	request, err := getLeaderboardRequest(r)
+	if err != nil {
+		writeError(w, response.Type, err)
+		return
+	}
 
+	page, err := GetLeaderboardPage(request)
 	if err != nil {
-		response.Status = "error"
-		response.Payload = ErrorPayload{
-			Message: "Wrong request",
-		}
-	} else {
-		userSlice, err := GetUsers(10, page)
+		writeError(w, response.Type, err)
+		return
+	}
 
-		if err != nil {
-			response.Status = "error"
-			response.Payload = ErrorPayload{
-				Message: err.Error(),
-			}
-		} else {
-			response.Status = "success"
-
-			dataSlice := make([]UserDataPayload, 0, len(userSlice))
-			for _, user := range userSlice {
-				dataSlice = append(dataSlice, UserDataPayload{
-					Name:  user.name,
-					Score: user.score,
-				})
-			}
-			count, _ := GetUserCount()
-			response.Payload = UsersPayload{
-				Users: dataSlice,
-				Count: count,
-			}
+	response.Status = "success"
+	response.Payload = usersPayload(page)
+	writeResponse(w, response)
+}
+
+// HandleGetLeaderboardAroundMe returns user rank with neighbours users above and below
+func HandleGetLeaderboardAroundMe(w http.ResponseWriter, r *http.Request, session *Session) {
+	response := Response{
+		Type: "uslist",
+	}
+	neighbours := defaultLeaderboardNeighbours
+	if value := r.URL.Query().Get("neighbours"); value != "" {
+		var err error
+		neighbours, err = strconv.Atoi(value)
+		if err != nil || neighbours < 0 || neighbours > maxLeaderboardNeighbours {
+			writeError(w, response.Type, &ValidationError{[]FieldError{{"neighbours", "out_of_range",
+				"neighbours must be from 0 to " + strconv.Itoa(maxLeaderboardNeighbours)}}})
+			return
 		}
 	}
-	byteResponse, _ := response.MarshalJSON()
-	w.Write(byteResponse)
+
+	page, rank, err := GetLeaderboardAround(session.user, neighbours)
+	if err != nil {
+		writeError(w, response.Type, err)
+		return
+	}
+
+	response.Status = "success"
+	payload := usersPayload(page)
+	payload.Rank = rank
+	response.Payload = payload
+	writeResponse(w, response)
 }
 
-func HandleGetUserData(w http.ResponseWriter, r *http.Request, session *Session) {
-	user := session.user
-	response := Response{
-		Type:   "usinfo",
-		Status: "success",
+func usersPayload(page *LeaderboardPage) UsersPayload {
+	dataSlice := make([]UserDataPayload, 0, len(page.Users))
+	for i, user := range page.Users {
+		dataSlice = append(dataSlice, UserDataPayload{
+			Name:    user.name,
+			Avatars: avatarURLs(&user),
+			Score:   user.score,
+			Rank:    page.Offset + i + 1,
+			Rating:  ratingPayload(&user),
+		})
+	}
+	payload := UsersPayload{
+		Users: dataSlice,
+		Count: page.Total,
+		Next:  page.Next(),
+		Prev:  page.Prev(),
 	}
+	if page.Period != nil {
+		payload.Period = page.Period.Name
+		payload.Start = page.Period.Start.Format(time.RFC3339)
+		payload.End = page.Period.End.Format(time.RFC3339)
+	}
+	return payload
+}
 
-	response.Payload = UserDataPayload{
+// userPayload is everything user sees about themselves
+func userPayload(user *User) UserDataPayload {
+	return UserDataPayload{
 		Login:      user.login,
 		Email:      user.email,
 		Name:       user.name,
-		AvatarPath: user.avatar,
+		AvatarPath: avatarURL(user),
+		Avatars:    avatarURLs(user),
 		Score:      user.score,
+		Rank:       leaderboard.Rank(user.uuid),
+		Rating:     ratingPayload(user),
 	}
+}
 
-	byteResponse, _ := response.MarshalJSON()
-	w.Write(byteResponse)
+func ratingPayload(user *User) *RatingPayload {
+	return &RatingPayload{
+		Rating:       math.Round(user.rating.Rating*100) / 100,
+		Deviation:    math.Round(user.rating.Deviation*100) / 100,
+		Volatility:   math.Round(user.rating.Volatility*1e6) / 1e6,
+		Conservative: user.rating.Conservative(),
+	}
+}
+
+func HandleGetUserData(w http.ResponseWriter, r *http.Request, session *Session) {
+	user := session.user
+	response := Response{
+		Type:   "usinfo",
+		Status: "success",
+	}
+
+	response.Payload = userPayload(user)
+
+	writeResponse(w, response)
 }
 
 func HandleUpdateUser(w http.ResponseWriter, r *http.Request, session *Session) {
@@ -199,51 +302,525 @@ func HandleUpdateUser(w http.ResponseWriter, r *http.Request, session *Session)
 
 	err := getRequest(userData, r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, "usinfo", err)
 		return
 	}
 
-	user := session.user
-
-	if userData.Name != "" {
-		user.name = userData.Name
+	// only name and password can be changed, login is there for password rules
+	userData = &UsrRequest{
+		Login:    session.user.login,
+		Name:     userData.Name,
+		Password: userData.Password,
+	}
+	err = ValidateUsrRequest(userData, false)
+	if err != nil {
+		writeError(w, "usinfo", err)
+		return
 	}
 
+	// hashing is slow on purpose, it must not hold the leaderboard lock UpdateUser takes
+	passwordHash := ""
 	if userData.Password != "" {
-		user.passwordHash = userData.Password
+		passwordHash, err = HashPassword(userData.Password)
+		if err != nil {
+			writeError(w, "usinfo", err)
+			return
+		}
 	}
 
-	user.Save()
+	user, err := UpdateUser(session.user.uuid, func(user *User) error {
+		if userData.Name != "" {
+			user.name = userData.Name
+		}
+
+		if passwordHash != "" {
+			user.passwordHash = passwordHash
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, "usinfo", err)
+		return
+	}
+	session.user = user
+
+	if userData.Password != "" {
+		err = renewSession(w, session)
+		if err != nil {
+			writeError(w, "usinfo", err)
+			return
+		}
+	}
 
 	response := Response{
 		Type:   "usinfo",
 		Status: "success",
 	}
 
-	response.Payload = UserDataPayload{
-		Login:      user.login,
-		Email:      user.email,
-		Name:       user.name,
-		AvatarPath: user.avatar,
-		Score:      user.score,
+	response.Payload = userPayload(user)
+
+	writeResponse(w, response)
+}
+
+// HandleGetHistory pages through score changes of user, newest first
+func HandleGetHistory(w http.ResponseWriter, r *http.Request, session *Session) {
+	response := Response{
+		Type: "history",
+	}
+	count := 0
+	if value := r.URL.Query().Get("count"); value != "" {
+		var err error
+		count, err = strconv.Atoi(value)
+		if err != nil || count < 1 || count > maxHistoryCount {
+			writeError(w, response.Type, &ValidationError{[]FieldError{{"count", "out_of_range",
+				"count must be from 1 to " + strconv.Itoa(maxHistoryCount)}}})
+			return
+		}
 	}
 
+	page, err := GetScoreHistory(session.user, count, r.URL.Query().Get("cursor"))
+	if err != nil {
+		writeError(w, response.Type, err)
+		return
+	}
+
+	eventSlice := make([]ScoreEventPayload, 0, len(page.Events))
+	for _, event := range page.Events {
+		eventSlice = append(eventSlice, ScoreEventPayload{
+			Delta:  event.delta,
+			Match:  event.match,
+			Source: event.source,
+			Time:   event.at.UTC().Format(time.RFC3339),
+		})
+	}
+	response.Status = "success"
+	response.Payload = HistoryPayload{eventSlice, page.Next}
+	writeResponse(w, response)
+}
+
+// HandleCSRFToken gives token that has to be sent in X-CSRF-Token header
+// with every POST, PUT and DELETE request.
+// Anonymous clients get it in a cookie as well, so nothing is stored
+// for them until they log in or register.
+func HandleCSRFToken(w http.ResponseWriter, r *http.Request, session *Session) {
+	var token string
+	var err error
+	if session.stored {
+		var isNew bool
+		token, isNew, err = session.CSRFToken()
+		if err == nil && isNew {
+			err = session.Save()
+		}
+	} else if cookie, cookieErr := r.Cookie(csrfCookie); cookieErr == nil && cookie.Value != "" {
+		// other tabs may still use the token already given out
+		token = cookie.Value
+	} else {
+		// nothing is stored for anonymous clients, the token is checked against the cookie
+		token, err = newCSRFToken()
+		if err == nil {
+			http.SetCookie(w, newCookie(csrfCookie, token, 0, time.Time{}))
+		}
+	}
+	if err != nil {
+		writeError(w, "csrf", err)
+		return
+	}
+
+	response := Response{
+		Type:   "csrf",
+		Status: "success",
+		Payload: CSRFPayload{
+			Token: token,
+		},
+	}
+
+	writeResponse(w, response)
+}
+
+func HandleLogout(w http.ResponseWriter, r *http.Request, session *Session) {
+	err := session.Delete()
+	if err != nil {
+		writeError(w, "logout", err)
+		return
+	}
+	clearSessionCookie(w)
+
+	response := Response{
+		Type:   "logout",
+		Status: "success",
+	}
+
+	writeResponse(w, response)
+}
+
+func HandleGetSessions(w http.ResponseWriter, r *http.Request, session *Session) {
+	sessionSlice, err := GetUserSessions(session.user)
+	if err != nil {
+		writeError(w, "sessions", err)
+		return
+	}
+
+	dataSlice := make([]SessionPayload, 0, len(sessionSlice))
+	for _, userSession := range sessionSlice {
+		dataSlice = append(dataSlice, SessionPayload{
+			ID:        userSession.id,
+			UserAgent: userSession.userAgent,
+			Current:   userSession.sid == session.sid,
+		})
+	}
+
+	response := Response{
+		Type:   "sessions",
+		Status: "success",
+		Payload: SessionsPayload{
+			Sessions: dataSlice,
+		},
+	}
+
+	writeResponse(w, response)
+}
+
+// HandleDeleteSession logs user out on one of devices,
+// deleting current session is the same as logout
+func HandleDeleteSession(w http.ResponseWriter, r *http.Request, session *Session) {
+	id := mux.Vars(r)["id"]
+	sessionSlice, err := GetUserSessions(session.user)
+	if err != nil {
+		writeError(w, "sessions", err)
+		return
+	}
+
+	for _, userSession := range sessionSlice {
+		if userSession.id != id {
+			continue
+		}
+
+		if userSession.sid == session.sid {
+			err = session.Delete()
+			clearSessionCookie(w)
+		} else {
+			err = userSession.Delete()
+		}
+		if err != nil {
+			writeError(w, "sessions", err)
+			return
+		}
+
+		response := Response{
+			Type:   "sessions",
+			Status: "success",
+		}
+		writeResponse(w, response)
+		return
+	}
+
+	writeError(w, "sessions", notFound("session not found"))
+}
+
+func HandleGameStart(w http.ResponseWriter, r *http.Request, session *Session) {
+	game := StartGame(session.user)
+
+	response := Response{
+		Type:   "game",
+		Status: "success",
+		Payload: GamePayload{
+			Token:       game.token,
+			MaxDuration: int(gameRules.MaxDuration.Seconds()),
+		},
+	}
+
+	writeResponse(w, response)
+}
+
+func HandleScore(w http.ResponseWriter, r *http.Request, session *Session) {
+	scoreData := &ScoreRequest{}
+
+	err := getRequest(scoreData, r)
+	if err != nil {
+		writeError(w, "score", err)
+		return
+	}
+
+	response := Response{
+		Type: "score",
+	}
+
+	user, err := FinishGame(session.user, scoreData.Token, scoreData.Score)
+	if err != nil {
+		writeError(w, response.Type, err)
+		return
+	}
+
+	session.user = user
+	response.Status = "success"
+	response.Payload = userPayload(user)
+
+	writeResponse(w, response)
+}
+
+// HandleMatchResult takes result of a match against another player,
+// ratings change when both players have reported the same result
+func HandleMatchResult(w http.ResponseWriter, r *http.Request, session *Session) {
+	matchData := &MatchRequest{}
+
+	err := getRequest(matchData, r)
+	if err != nil {
+		writeError(w, "match", err)
+		return
+	}
+
+	response := Response{
+		Type: "match",
+	}
+
+	user, err := ReportMatch(session.user, matchData.Match, matchData.Opponent, matchData.Result)
+	if err != nil {
+		writeError(w, response.Type, err)
+		return
+	}
+
+	response.Status = "success"
+	if user == nil {
+		response.Payload = MatchPayload{Status: "pending"}
+	} else {
+		session.user = user
+		response.Payload = MatchPayload{Status: "rated", Rating: ratingPayload(user)}
+	}
+	writeResponse(w, response)
+}
+
+func matchmakingPayload(uuid uint32) MatchmakingPayload {
+	entry, window, pairing := matchQueue.Lookup(uuid)
+	switch {
+	case entry != nil:
+		return MatchmakingPayload{
+			Status: "queued",
+			Since:  entry.joined.UTC().Format(time.RFC3339),
+			Window: window,
+		}
+	case pairing != nil:
+		return MatchmakingPayload{
+			Status:   "matched",
+			Room:     pairing.room,
+			Opponent: pairing.Opponent(uuid).login,
+		}
+	}
+	return MatchmakingPayload{Status: "idle"}
+}
+
+func HandleGetMatchmaking(w http.ResponseWriter, r *http.Request, session *Session) {
+	writeResponse(w, Response{
+		Type:    "matchmaking",
+		Status:  "success",
+		Payload: matchmakingPayload(session.user.uuid),
+	})
+}
+
+// HandleEnqueue puts user in the matchmaking queue, the answer is matched
+// if an opponent is waiting already. Otherwise user learns about the match
+// over websocket or by asking HandleGetMatchmaking.
+func HandleEnqueue(w http.ResponseWriter, r *http.Request, session *Session) {
+	err := EnqueueUser(session.user.uuid)
+	if err != nil {
+		writeError(w, "matchmaking", err)
+		return
+	}
+	Matchmake()
+	HandleGetMatchmaking(w, r, session)
+}
+
+func HandleDequeue(w http.ResponseWriter, r *http.Request, session *Session) {
+	if !matchQueue.Dequeue(session.user.uuid) {
+		writeError(w, "matchmaking", ErrNotQueued)
+		return
+	}
+	HandleGetMatchmaking(w, r, session)
+}
+
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrUnauthorized     = errors.New("authorization needed")
+	ErrBadCSRFToken     = errors.New("missing or wrong CSRF token")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+// HandleNotFound answers requests no route matches
+func HandleNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, "route", notFound("no such route"))
+}
+
+// HandleMethodNotAllowed answers requests with a method their route does not take
+func HandleMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, "route", ErrMethodNotAllowed)
+}
+
+// writeError is the only place where errors become HTTP responses,
+// status and error payload by the kind of err come from errorResponse
+func writeError(w http.ResponseWriter, responseType string, err error) {
+	status, response := errorResponse(responseType, err)
+	var retryErr *RetryLaterError
+	if errors.As(err, &retryErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	byteResponse, _ := response.MarshalJSON()
+	w.Write(byteResponse)
+}
+
+// errorResponse picks HTTP status and error payload by the kind of err,
+// websocket errors get the same payloads
+func errorResponse(responseType string, err error) (int, Response) {
+	status := http.StatusInternalServerError
+	payload := ErrorPayload{
+		Message: "internal error",
+		Code:    "internal",
+	}
+
+	var validationErr *ValidationError
+	var gameErr *GameError
+	var retryErr *RetryLaterError
+	switch {
+	case errors.As(err, &validationErr):
+		status = http.StatusUnprocessableEntity
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Field:   validationErr.Errors[0].Field,
+			Code:    "validation",
+			Errors:  make([]FieldErrorPayload, 0, len(validationErr.Errors)),
+		}
+		for _, fieldError := range validationErr.Errors {
+			payload.Errors = append(payload.Errors, FieldErrorPayload{
+				Field:   fieldError.Field,
+				Code:    fieldError.Code,
+				Message: fieldError.Message,
+			})
+		}
+	case errors.As(err, &gameErr):
+		status = http.StatusUnprocessableEntity
+		payload = ErrorPayload{
+			Message: gameErr.Message,
+			Field:   gameErr.Field,
+			Code:    gameErr.Code,
+		}
+	case errors.As(err, &retryErr):
+		status = http.StatusTooManyRequests
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Code:    "too_many_attempts",
+		}
+	case errors.Is(err, ErrUserExists):
+		status = http.StatusConflict
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Field:   "login",
+			Code:    "user_exists",
+		}
+	case errors.Is(err, ErrBadLogin):
+		status = http.StatusUnauthorized
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Field:   "login",
+			Code:    "bad_login",
+		}
+	case errors.Is(err, ErrBadPassword):
+		status = http.StatusUnauthorized
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Field:   "password",
+			Code:    "bad_password",
+		}
+	case errors.Is(err, ErrBadCredentials):
+		status = http.StatusUnauthorized
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Code:    "bad_credentials",
+		}
+	case errors.Is(err, ErrBadRequest):
+		status = http.StatusBadRequest
+		payload = ErrorPayload{
+			Message: "bad request",
+			Code:    "bad_request",
+		}
+	case errors.Is(err, ErrUnauthorized):
+		status = http.StatusUnauthorized
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Code:    "unauthorized",
+		}
+	case errors.Is(err, ErrMethodNotAllowed):
+		status = http.StatusMethodNotAllowed
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Code:    "method_not_allowed",
+		}
+	case errors.Is(err, ErrBadCSRFToken):
+		status = http.StatusForbidden
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Code:    "csrf",
+		}
+	case errors.Is(err, ErrAvatarTooLarge):
+		status = http.StatusRequestEntityTooLarge
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Field:   "avatar",
+			Code:    "too_large",
+		}
+	case errors.Is(err, ErrAvatarFormat):
+		status = http.StatusUnsupportedMediaType
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Field:   "avatar",
+			Code:    "unsupported_format",
+		}
+	case errors.Is(err, ErrAvatarBroken):
+		status = http.StatusUnprocessableEntity
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Field:   "avatar",
+			Code:    "broken_image",
+		}
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+		payload = ErrorPayload{
+			Message: err.Error(),
+			Code:    "not_found",
+		}
+	default:
+		log.Println(responseType, "request failed:", err)
+	}
+
+	return status, Response{
+		Type:    responseType,
+		Status:  "error",
+		Payload: payload,
+	}
+}
+
+// writeResponse sends successful response
+func writeResponse(w http.ResponseWriter, response Response) {
+	w.Header().Set("Content-Type", "application/json")
 	byteResponse, _ := response.MarshalJSON()
 	w.Write(byteResponse)
 }
 
+func badRequest(err error) error {
+	return fmt.Errorf("%w: %v", ErrBadRequest, err)
+}
+
 func getRequest(marshaler json.Unmarshaler, r *http.Request) error {
 	body := r.Body
 	defer body.Close()
 	byteBody, err := ioutil.ReadAll(body)
 	if err != nil {
-		return err
+		return badRequest(err)
 	}
 
 	err = marshaler.UnmarshalJSON(byteBody)
 
 	if err != nil {
-		return err
+		return badRequest(err)
 	}
 	return nil
 }
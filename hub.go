@@ -0,0 +1,422 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HubConfig tunes multiplayer rooms
+type HubConfig struct {
+	// TickInterval is how often rooms send inputs collected since the last tick to players
+	TickInterval time.Duration
+	// DisconnectGrace is how long a disconnected player keeps the place in a room
+	DisconnectGrace time.Duration
+	DefaultRoomSize int
+	MaxRoomSize     int
+	// MaxInputs is how many inputs a player may send during one tick
+	MaxInputs int
+}
+
+var hubConfig = HubConfig{
+	TickInterval:    50 * time.Millisecond,
+	DisconnectGrace: 15 * time.Second,
+	DefaultRoomSize: 2,
+	MaxRoomSize:     8,
+	MaxInputs:       5,
+}
+
+const (
+	RoomWaiting = "waiting"
+	RoomPlaying = "playing"
+	RoomClosed  = "closed"
+)
+
+var (
+	ErrRoomFull       = &GameError{"room_full", "room", "room is full"}
+	ErrRoomReserved   = &GameError{"reserved", "room", "room is for other players"}
+	ErrAlreadyInRoom  = &GameError{"already_in_room", "room", "leave your room first"}
+	ErrNotInRoom      = &GameError{"not_in_room", "room", "you are not in this room"}
+	ErrRoomNotStarted = &GameError{"not_started", "room", "game has not started yet"}
+	ErrTooManyInputs  = &GameError{"too_many_inputs", "data", "too many inputs this tick"}
+)
+
+// Room is a game of a few players. It waits until size players join,
+// then every tick sends all of them inputs players sent during the tick,
+// in the order they arrived, so every player applies the same inputs
+// in the same order. Room closes when fewer than two players are left
+// in a started game, or nobody is left in a waiting one.
+type Room struct {
+	id       string
+	size     int
+	state    string
+	players  []*RoomPlayer
+	reserved map[uint32]bool // only these users may join if set
+	tick     int
+	inputs   []TickInputPayload
+	stop     chan struct{}
+}
+
+// RoomPlayer is a user connected to the hub, client is nil while user is disconnected
+type RoomPlayer struct {
+	uuid   uint32
+	login  string
+	name   string
+	client *Client
+	room   *Room
+	inputs int
+	grace  *time.Timer
+}
+
+// Hub keeps rooms and players connected over websocket.
+// Everything changes under one lock, rooms are small and few.
+type Hub struct {
+	mu      sync.Mutex
+	rooms   map[string]*Room
+	players map[uint32]*RoomPlayer
+}
+
+var hub = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{
+		rooms:   make(map[string]*Room),
+		players: make(map[uint32]*RoomPlayer),
+	}
+}
+
+// Connect attaches client to user. Connection of the same user made before
+// is closed, a player disconnected from a room gets back to it.
+func (hub *Hub) Connect(user *User, client *Client) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	player, ok := hub.players[user.uuid]
+	if !ok {
+		player = &RoomPlayer{uuid: user.uuid, login: user.login}
+		hub.players[user.uuid] = player
+	}
+	player.name = user.name
+	if player.client != nil {
+		player.client.Close()
+	}
+	player.client = client
+	if player.grace != nil {
+		player.grace.Stop()
+		player.grace = nil
+	}
+	if player.room != nil {
+		hub.broadcast(player.room, roomMessage(player.room))
+	}
+}
+
+// Disconnect detaches client from user, if it still is user client.
+// A player in a room keeps the place for DisconnectGrace.
+func (hub *Hub) Disconnect(uuid uint32, client *Client) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	player, ok := hub.players[uuid]
+	if !ok || player.client != client {
+		return
+	}
+	player.client = nil
+	if player.room == nil {
+		delete(hub.players, uuid)
+		return
+	}
+	player.grace = time.AfterFunc(hubConfig.DisconnectGrace, func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+
+		if hub.players[uuid] == player && player.client == nil {
+			hub.leave(player)
+			delete(hub.players, uuid)
+		}
+	})
+	hub.broadcast(player.room, roomMessage(player.room))
+}
+
+// Handle carries out request of user, errors go back to user only
+func (hub *Hub) Handle(uuid uint32, request *WSRequest) {
+	if request.Type == "queue" || request.Type == "dequeue" {
+		hub.queue(uuid, request.Type)
+		return
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	player, ok := hub.players[uuid]
+	if !ok {
+		return
+	}
+	var err error
+	switch request.Type {
+	case "create":
+		err = hub.create(player, request.Size)
+	case "join":
+		err = hub.join(player, request.Room)
+	case "leave":
+		if player.room == nil || player.room.id != request.Room {
+			err = ErrNotInRoom
+		} else {
+			hub.leave(player)
+		}
+	case "input":
+		err = hub.input(player, request.Room, request.Data)
+	default:
+		err = badRequest(fmt.Errorf("unknown request type %q", request.Type))
+	}
+	if err != nil && player.client != nil {
+		_, response := errorResponse(request.Type, err)
+		message, _ := response.MarshalJSON()
+		player.client.Send(message)
+	}
+}
+
+// queue puts user in the matchmaking queue or takes them out of it.
+// It runs without the lock, matchmaking locks the hub itself.
+func (hub *Hub) queue(uuid uint32, requestType string) {
+	var err error
+	if requestType == "queue" {
+		err = EnqueueUser(uuid)
+	} else if !matchQueue.Dequeue(uuid) {
+		err = ErrNotQueued
+	}
+
+	response := Response{
+		Type:    "matchmaking",
+		Status:  "success",
+		Payload: matchmakingPayload(uuid),
+	}
+	if err != nil {
+		_, response = errorResponse(response.Type, err)
+	}
+	message, _ := response.MarshalJSON()
+	hub.Send(uuid, message)
+	if err == nil && requestType == "queue" {
+		Matchmake()
+	}
+}
+
+// Reserve creates a room for users, it closes if they don't all join within timeout
+func (hub *Hub) Reserve(id string, users []uint32, timeout time.Duration) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	room := &Room{id: id, size: len(users), state: RoomWaiting, reserved: make(map[uint32]bool)}
+	for _, uuid := range users {
+		room.reserved[uuid] = true
+	}
+	hub.rooms[id] = room
+	time.AfterFunc(timeout, func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+
+		if room.state == RoomWaiting {
+			hub.close(room)
+		}
+	})
+}
+
+// RoomOf returns id of the room user is in, or an empty string
+func (hub *Hub) RoomOf(uuid uint32) string {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	player, ok := hub.players[uuid]
+	if !ok || player.room == nil {
+		return ""
+	}
+	return player.room.id
+}
+
+// Send sends message to user if user is connected
+func (hub *Hub) Send(uuid uint32, message []byte) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if player, ok := hub.players[uuid]; ok && player.client != nil {
+		player.client.Send(message)
+	}
+}
+
+func (hub *Hub) create(player *RoomPlayer, size int) error {
+	if player.room != nil {
+		return ErrAlreadyInRoom
+	}
+	if size == 0 {
+		size = hubConfig.DefaultRoomSize
+	}
+	if size < 2 || size > hubConfig.MaxRoomSize {
+		return &ValidationError{[]FieldError{{"size", "out_of_range",
+			"size must be from 2 to " + strconv.Itoa(hubConfig.MaxRoomSize)}}}
+	}
+	room := &Room{id: uuid.New().String(), size: size, state: RoomWaiting}
+	hub.rooms[room.id] = room
+	return hub.join(player, room.id)
+}
+
+func (hub *Hub) join(player *RoomPlayer, id string) error {
+	if player.room != nil {
+		return ErrAlreadyInRoom
+	}
+	room, ok := hub.rooms[id]
+	if !ok {
+		return notFound("no such room")
+	}
+	if room.reserved != nil && !room.reserved[player.uuid] {
+		return ErrRoomReserved
+	}
+	if room.state != RoomWaiting || len(room.players) >= room.size {
+		return ErrRoomFull
+	}
+
+	room.players = append(room.players, player)
+	player.room = room
+	if len(room.players) == room.size {
+		hub.start(room)
+	}
+	hub.broadcast(room, roomMessage(room))
+	return nil
+}
+
+func (hub *Hub) start(room *Room) {
+	room.state = RoomPlaying
+	// one on one games are rated, the room is the match players report
+	if len(room.players) == 2 {
+		IssueMatch(room.id, [2]uint32{room.players[0].uuid, room.players[1].uuid}, timeNow())
+	}
+	room.inputs = make([]TickInputPayload, 0)
+	room.stop = make(chan struct{})
+	ticker := time.NewTicker(hubConfig.TickInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				hub.step(room)
+			case <-room.stop:
+				return
+			}
+		}
+	}()
+}
+
+// step sends inputs collected during the tick to players of room
+func (hub *Hub) step(room *Room) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if room.state != RoomPlaying {
+		return
+	}
+	room.tick++
+	response := Response{
+		Type:    "tick",
+		Status:  "success",
+		Payload: TickPayload{Room: room.id, Tick: room.tick, Inputs: room.inputs},
+	}
+	message, _ := response.MarshalJSON()
+	room.inputs = make([]TickInputPayload, 0)
+	for _, player := range room.players {
+		player.inputs = 0
+	}
+	hub.broadcast(room, message)
+}
+
+func (hub *Hub) input(player *RoomPlayer, id string, data json.RawMessage) error {
+	room := player.room
+	if room == nil || room.id != id {
+		return ErrNotInRoom
+	}
+	if room.state != RoomPlaying {
+		return ErrRoomNotStarted
+	}
+	if player.inputs >= hubConfig.MaxInputs {
+		return ErrTooManyInputs
+	}
+	player.inputs++
+	room.inputs = append(room.inputs, TickInputPayload{Player: player.login, Data: data})
+	return nil
+}
+
+func (hub *Hub) leave(player *RoomPlayer) {
+	room := player.room
+	player.room = nil
+	player.inputs = 0
+	for i, roomPlayer := range room.players {
+		if roomPlayer == player {
+			room.players = append(room.players[:i], room.players[i+1:]...)
+			break
+		}
+	}
+
+	if len(room.players) == 0 || (room.state == RoomPlaying && len(room.players) < 2) {
+		hub.close(room)
+	} else {
+		hub.broadcast(room, roomMessage(room))
+	}
+	// the one who left sees the room without themselves
+	if player.client != nil {
+		player.client.Send(roomMessage(room))
+	}
+}
+
+// close stops room and lets its players go, the ones disconnected are forgotten
+func (hub *Hub) close(room *Room) {
+	if room.state == RoomPlaying {
+		close(room.stop)
+	}
+	room.state = RoomClosed
+	delete(hub.rooms, room.id)
+	hub.broadcast(room, roomMessage(room))
+
+	for _, player := range room.players {
+		player.room = nil
+		player.inputs = 0
+		if player.client == nil {
+			if player.grace != nil {
+				player.grace.Stop()
+			}
+			delete(hub.players, player.uuid)
+		}
+	}
+	room.players = nil
+}
+
+func (hub *Hub) broadcast(room *Room, message []byte) {
+	for _, player := range room.players {
+		if player.client != nil {
+			player.client.Send(message)
+		}
+	}
+}
+
+func roomMessage(room *Room) []byte {
+	players := make([]RoomPlayerPayload, 0, len(room.players))
+	for _, player := range room.players {
+		players = append(players, RoomPlayerPayload{
+			Login:     player.login,
+			Name:      player.name,
+			Connected: player.client != nil,
+		})
+	}
+	response := Response{
+		Type:   "room",
+		Status: "success",
+		Payload: RoomPayload{
+			ID:      room.id,
+			State:   room.state,
+			Size:    room.size,
+			Players: players,
+		},
+	}
+	message, _ := response.MarshalJSON()
+	return message
+}
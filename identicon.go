@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	identiconGrid = 5
+	// identiconVersion goes to ETag, bump it when pictures change
+	identiconVersion    = "1"
+	defaultAvatarPrefix = "/media/avatar/default/"
+)
+
+var identiconBackground = color.RGBA{240, 240, 240, 255}
+
+// Identicon is a symmetric grid picture derived from user uuid,
+// the same uuid always gives the same picture
+type Identicon struct {
+	cells [identiconGrid][identiconGrid]bool
+	color color.RGBA
+}
+
+func NewIdenticon(uuid uint32) Identicon {
+	seed := make([]byte, 4)
+	binary.BigEndian.PutUint32(seed, uuid)
+	// hash, so that neighbouring uuids look nothing alike
+	sum := sha256.Sum256(seed)
+
+	identicon := Identicon{
+		color: hslColor(float64(binary.BigEndian.Uint16(sum[:2]))/65536*360, 0.55, 0.5),
+	}
+	bit := 0
+	for x := 0; x < (identiconGrid+1)/2; x++ {
+		for y := 0; y < identiconGrid; y++ {
+			on := sum[2+bit/8]>>(uint(bit)%8)&1 == 1
+			identicon.cells[y][x] = on
+			identicon.cells[y][identiconGrid-1-x] = on
+			bit++
+		}
+	}
+	return identicon
+}
+
+func hslColor(hue float64, saturation float64, lightness float64) color.RGBA {
+	chroma := (1 - math.Abs(2*lightness-1)) * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g = chroma, x
+	case hue < 120:
+		r, g = x, chroma
+	case hue < 180:
+		g, b = chroma, x
+	case hue < 240:
+		g, b = x, chroma
+	case hue < 300:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := lightness - chroma/2
+	return color.RGBA{uint8((r + m) * 255), uint8((g + m) * 255), uint8((b + m) * 255), 255}
+}
+
+// Image draws identicon as size x size picture with half a cell margin
+func (identicon Identicon) Image(size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	cell := float64(size) / (identiconGrid + 1)
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			column := int(math.Floor(float64(x)/cell - 0.5))
+			row := int(math.Floor(float64(y)/cell - 0.5))
+			if column >= 0 && column < identiconGrid && row >= 0 && row < identiconGrid && identicon.cells[row][column] {
+				img.SetRGBA(x, y, identicon.color)
+			} else {
+				img.SetRGBA(x, y, identiconBackground)
+			}
+		}
+	}
+	return img
+}
+
+func (identicon Identicon) SVG() []byte {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintf(buffer, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, 2*identiconGrid+2, 2*identiconGrid+2)
+	fmt.Fprintf(buffer, `<rect width="100%%" height="100%%" fill="#%02x%02x%02x"/>`,
+		identiconBackground.R, identiconBackground.G, identiconBackground.B)
+	fmt.Fprintf(buffer, `<g fill="#%02x%02x%02x">`, identicon.color.R, identicon.color.G, identicon.color.B)
+	for row := 0; row < identiconGrid; row++ {
+		for column := 0; column < identiconGrid; column++ {
+			if identicon.cells[row][column] {
+				fmt.Fprintf(buffer, `<rect x="%d" y="%d" width="2" height="2"/>`, 2*column+1, 2*row+1)
+			}
+		}
+	}
+	buffer.WriteString(`</g></svg>`)
+	return buffer.Bytes()
+}
+
+// defaultAvatarURL is where generated avatar of user is served,
+// size 0 stands for the biggest thumbnail size
+func defaultAvatarURL(uuid uint32, size int) string {
+	url := defaultAvatarPrefix + strconv.FormatUint(uint64(uuid), 10)
+	if size != 0 {
+		url += "?size=" + strconv.Itoa(size)
+	}
+	return url
+}
+
+// HandleDefaultAvatar serves identicon of uuid as png,
+// or as svg if path ends with .svg. Png size is one of avatar thumbnail sizes.
+func HandleDefaultAvatar(w http.ResponseWriter, r *http.Request) {
+	uuid, err := strconv.ParseUint(mux.Vars(r)["uuid"], 10, 32)
+	if err != nil {
+		writeError(w, "avatar", notFound("no such avatar"))
+		return
+	}
+	svg := strings.HasSuffix(r.URL.Path, ".svg")
+
+	size := avatarConfig.Sizes[len(avatarConfig.Sizes)-1]
+	if value := r.URL.Query().Get("size"); value != "" && !svg {
+		size, err = strconv.Atoi(value)
+		known := false
+		for _, knownSize := range avatarConfig.Sizes {
+			known = known || knownSize == size
+		}
+		if err != nil || !known {
+			writeError(w, "avatar", badRequest(fmt.Errorf("unknown size %q", value)))
+			return
+		}
+	}
+
+	etag := fmt.Sprintf(`"identicon-%s-%d-%d-%t"`, identiconVersion, uuid, size, svg)
+	w.Header().Set("Cache-Control", "public, max-age=604800")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	identicon := NewIdenticon(uint32(uuid))
+	if svg {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write(identicon.SVG())
+		return
+	}
+	buffer := &bytes.Buffer{}
+	err = png.Encode(buffer, identicon.Image(size))
+	if err != nil {
+		writeError(w, "avatar", err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buffer.Bytes())
+}
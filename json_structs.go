@@ -1,5 +1,7 @@
 package main
 
+import "encoding/json"
+
 type Response struct {
 	Type    string      `json:"type"`
 	Status  string      `json:"status"`
@@ -9,19 +11,80 @@ type Response struct {
 type UsersPayload struct {
 	Users []UserDataPayload `json:"users"`
 	Count int               `json:"count"`
+	Next  string            `json:"next,omitempty"` // cursor of the next page
+	Prev  string            `json:"prev,omitempty"` // cursor of the previous page
+	Rank  int               `json:"rank,omitempty"` // rank of the user around whom the page is
+	// period leaderboards only
+	Period string `json:"period,omitempty"`
+	Start  string `json:"start,omitempty"`
+	End    string `json:"end,omitempty"`
 }
 
 type UserDataPayload struct {
-	Login      string `json:"login,omitempty"`
-	Email      string `json:"email,omitempty"`
-	Name       string `json:"name,omitempty"`
-	AvatarPath string `json:"avatar,omitempty"`
-	Score      int    `json:"score"`
+	Login      string            `json:"login,omitempty"`
+	Email      string            `json:"email,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	AvatarPath string            `json:"avatar,omitempty"`
+	Avatars    map[string]string `json:"avatars,omitempty"` // thumbnail size to URL
+	Score      int               `json:"score"`
+	Rank       int               `json:"rank,omitempty"`
+	Rating     *RatingPayload    `json:"rating,omitempty"`
+}
+
+type RatingPayload struct {
+	Rating       float64 `json:"rating"`
+	Deviation    float64 `json:"deviation"`
+	Volatility   float64 `json:"volatility"`
+	Conservative int     `json:"conservative"`
+}
+
+type MatchPayload struct {
+	Status string         `json:"status"` // "pending" until the opponent reports, "rated" then
+	Rating *RatingPayload `json:"rating,omitempty"`
+}
+
+type HistoryPayload struct {
+	Events []ScoreEventPayload `json:"events"`
+	Next   string              `json:"next,omitempty"` // cursor of the next page
+}
+
+type ScoreEventPayload struct {
+	Delta  int    `json:"delta"`
+	Match  string `json:"match,omitempty"`
+	Source string `json:"source"`
+	Time   string `json:"time"`
+}
+
+type SessionsPayload struct {
+	Sessions []SessionPayload `json:"sessions"`
+}
+
+type SessionPayload struct {
+	ID        string `json:"id"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Current   bool   `json:"current,omitempty"`
+}
+
+type CSRFPayload struct {
+	Token string `json:"token"`
 }
 
 type ErrorPayload struct {
-	Message string `json:"message,omitempty"`
-	Field   string `json:"field,omitempty"`
+	Message string              `json:"message,omitempty"`
+	Field   string              `json:"field,omitempty"`
+	Code    string              `json:"code,omitempty"`
+	Errors  []FieldErrorPayload `json:"errors,omitempty"`
+}
+
+type FieldErrorPayload struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type GamePayload struct {
+	Token       string `json:"token"`
+	MaxDuration int    `json:"max_duration"`
 }
 
 type UsrRequest struct {
@@ -32,6 +95,61 @@ type UsrRequest struct {
 }
 
 type LeaderboardRequest struct {
-	Count int `json:"count"`
-	Page  int `json:"page"`
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+	Page   int    `json:"page"`
+	Cursor string `json:"cursor"`
+	Date   string `json:"date"`
+}
+
+type MatchRequest struct {
+	Match    string `json:"match"`
+	Opponent string `json:"opponent"` // login
+	Result   string `json:"result"`   // win, draw or loss
+}
+
+type ScoreRequest struct {
+	Token string `json:"token"`
+	Score int    `json:"score"`
+}
+
+// WSRequest is a message from websocket client, Data is game input
+// relayed to other players as is
+type WSRequest struct {
+	Type string          `json:"type"` // create, join, leave, input, queue or dequeue
+	Room string          `json:"room,omitempty"`
+	Size int             `json:"size,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+type RoomPayload struct {
+	ID      string              `json:"id"`
+	State   string              `json:"state"`
+	Size    int                 `json:"size"`
+	Players []RoomPlayerPayload `json:"players"`
+}
+
+type RoomPlayerPayload struct {
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Connected bool   `json:"connected"`
+}
+
+type TickPayload struct {
+	Room   string             `json:"room"`
+	Tick   int                `json:"tick"`
+	Inputs []TickInputPayload `json:"inputs"`
+}
+
+type TickInputPayload struct {
+	Player string          `json:"player"` // login
+	Data   json.RawMessage `json:"data"`
+}
+
+type MatchmakingPayload struct {
+	Status   string `json:"status"`           // idle, queued or matched
+	Since    string `json:"since,omitempty"`  // when the player was queued
+	Window   int    `json:"window,omitempty"` // rating difference the player accepts now
+	Room     string `json:"room,omitempty"`   // room to join when matched
+	Opponent string `json:"opponent,omitempty"`
 }
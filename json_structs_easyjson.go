@@ -17,7 +17,116 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson6a93d021DecodeTest(in *jlexer.Lexer, out *UsrRequest) {
+func easyjson6a93d021DecodeTest(in *jlexer.Lexer, out *WSRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "type":
+			out.Type = string(in.String())
+		case "room":
+			out.Room = string(in.String())
+		case "size":
+			out.Size = int(in.Int())
+		case "data":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Data).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest(out *jwriter.Writer, in WSRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"type\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Type))
+	}
+	if in.Room != "" {
+		const prefix string = ",\"room\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Room))
+	}
+	if in.Size != 0 {
+		const prefix string = ",\"size\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Size))
+	}
+	if len(in.Data) != 0 {
+		const prefix string = ",\"data\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((in.Data).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WSRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WSRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WSRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WSRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest(l, v)
+}
+func easyjson6a93d021DecodeTest1(in *jlexer.Lexer, out *UsrRequest) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -54,7 +163,7 @@ func easyjson6a93d021DecodeTest(in *jlexer.Lexer, out *UsrRequest) {
 		in.Consumed()
 	}
 }
-func easyjson6a93d021EncodeTest(out *jwriter.Writer, in UsrRequest) {
+func easyjson6a93d021EncodeTest1(out *jwriter.Writer, in UsrRequest) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -104,27 +213,27 @@ func easyjson6a93d021EncodeTest(out *jwriter.Writer, in UsrRequest) {
 // MarshalJSON supports json.Marshaler interface
 func (v UsrRequest) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson6a93d021EncodeTest(&w, v)
+	easyjson6a93d021EncodeTest1(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v UsrRequest) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson6a93d021EncodeTest(w, v)
+	easyjson6a93d021EncodeTest1(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *UsrRequest) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson6a93d021DecodeTest(&r, v)
+	easyjson6a93d021DecodeTest1(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *UsrRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson6a93d021DecodeTest(l, v)
+	easyjson6a93d021DecodeTest1(l, v)
 }
-func easyjson6a93d021DecodeTest1(in *jlexer.Lexer, out *UsersPayload) {
+func easyjson6a93d021DecodeTest2(in *jlexer.Lexer, out *UsersPayload) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -168,6 +277,18 @@ func easyjson6a93d021DecodeTest1(in *jlexer.Lexer, out *UsersPayload) {
 			}
 		case "count":
 			out.Count = int(in.Int())
+		case "next":
+			out.Next = string(in.String())
+		case "prev":
+			out.Prev = string(in.String())
+		case "rank":
+			out.Rank = int(in.Int())
+		case "period":
+			out.Period = string(in.String())
+		case "start":
+			out.Start = string(in.String())
+		case "end":
+			out.End = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -178,7 +299,7 @@ func easyjson6a93d021DecodeTest1(in *jlexer.Lexer, out *UsersPayload) {
 		in.Consumed()
 	}
 }
-func easyjson6a93d021EncodeTest1(out *jwriter.Writer, in UsersPayload) {
+func easyjson6a93d021EncodeTest2(out *jwriter.Writer, in UsersPayload) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -213,33 +334,93 @@ func easyjson6a93d021EncodeTest1(out *jwriter.Writer, in UsersPayload) {
 		}
 		out.Int(int(in.Count))
 	}
+	if in.Next != "" {
+		const prefix string = ",\"next\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Next))
+	}
+	if in.Prev != "" {
+		const prefix string = ",\"prev\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Prev))
+	}
+	if in.Rank != 0 {
+		const prefix string = ",\"rank\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Rank))
+	}
+	if in.Period != "" {
+		const prefix string = ",\"period\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Period))
+	}
+	if in.Start != "" {
+		const prefix string = ",\"start\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Start))
+	}
+	if in.End != "" {
+		const prefix string = ",\"end\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.End))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v UsersPayload) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson6a93d021EncodeTest1(&w, v)
+	easyjson6a93d021EncodeTest2(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v UsersPayload) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson6a93d021EncodeTest1(w, v)
+	easyjson6a93d021EncodeTest2(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *UsersPayload) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson6a93d021DecodeTest1(&r, v)
+	easyjson6a93d021DecodeTest2(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *UsersPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson6a93d021DecodeTest1(l, v)
+	easyjson6a93d021DecodeTest2(l, v)
 }
-func easyjson6a93d021DecodeTest2(in *jlexer.Lexer, out *UserDataPayload) {
+func easyjson6a93d021DecodeTest3(in *jlexer.Lexer, out *UserDataPayload) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -266,8 +447,40 @@ func easyjson6a93d021DecodeTest2(in *jlexer.Lexer, out *UserDataPayload) {
 			out.Name = string(in.String())
 		case "avatar":
 			out.AvatarPath = string(in.String())
+		case "avatars":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Avatars = make(map[string]string)
+				} else {
+					out.Avatars = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v4 string
+					v4 = string(in.String())
+					(out.Avatars)[key] = v4
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
 		case "score":
 			out.Score = int(in.Int())
+		case "rank":
+			out.Rank = int(in.Int())
+		case "rating":
+			if in.IsNull() {
+				in.Skip()
+				out.Rating = nil
+			} else {
+				if out.Rating == nil {
+					out.Rating = new(RatingPayload)
+				}
+				(*out.Rating).UnmarshalEasyJSON(in)
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -278,7 +491,7 @@ func easyjson6a93d021DecodeTest2(in *jlexer.Lexer, out *UserDataPayload) {
 		in.Consumed()
 	}
 }
-func easyjson6a93d021EncodeTest2(out *jwriter.Writer, in UserDataPayload) {
+func easyjson6a93d021EncodeTest3(out *jwriter.Writer, in UserDataPayload) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -322,6 +535,30 @@ func easyjson6a93d021EncodeTest2(out *jwriter.Writer, in UserDataPayload) {
 		}
 		out.String(string(in.AvatarPath))
 	}
+	if len(in.Avatars) != 0 {
+		const prefix string = ",\"avatars\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('{')
+			v5First := true
+			for v5Name, v5Value := range in.Avatars {
+				if v5First {
+					v5First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v5Name))
+				out.RawByte(':')
+				out.String(string(v5Value))
+			}
+			out.RawByte('}')
+		}
+	}
 	{
 		const prefix string = ",\"score\":"
 		if first {
@@ -332,33 +569,53 @@ func easyjson6a93d021EncodeTest2(out *jwriter.Writer, in UserDataPayload) {
 		}
 		out.Int(int(in.Score))
 	}
+	if in.Rank != 0 {
+		const prefix string = ",\"rank\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Rank))
+	}
+	if in.Rating != nil {
+		const prefix string = ",\"rating\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		(*in.Rating).MarshalEasyJSON(out)
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v UserDataPayload) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson6a93d021EncodeTest2(&w, v)
+	easyjson6a93d021EncodeTest3(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v UserDataPayload) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson6a93d021EncodeTest2(w, v)
+	easyjson6a93d021EncodeTest3(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *UserDataPayload) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson6a93d021DecodeTest2(&r, v)
+	easyjson6a93d021DecodeTest3(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *UserDataPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson6a93d021DecodeTest2(l, v)
+	easyjson6a93d021DecodeTest3(l, v)
 }
-func easyjson6a93d021DecodeTest3(in *jlexer.Lexer, out *Response) {
+func easyjson6a93d021DecodeTest4(in *jlexer.Lexer, out *TickPayload) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -377,17 +634,32 @@ func easyjson6a93d021DecodeTest3(in *jlexer.Lexer, out *Response) {
 			continue
 		}
 		switch key {
-		case "type":
-			out.Type = string(in.String())
-		case "status":
-			out.Status = string(in.String())
-		case "payload":
-			if m, ok := out.Payload.(easyjson.Unmarshaler); ok {
-				m.UnmarshalEasyJSON(in)
-			} else if m, ok := out.Payload.(json.Unmarshaler); ok {
-				_ = m.UnmarshalJSON(in.Raw())
+		case "room":
+			out.Room = string(in.String())
+		case "tick":
+			out.Tick = int(in.Int())
+		case "inputs":
+			if in.IsNull() {
+				in.Skip()
+				out.Inputs = nil
 			} else {
-				out.Payload = in.Interface()
+				in.Delim('[')
+				if out.Inputs == nil {
+					if !in.IsDelim(']') {
+						out.Inputs = make([]TickInputPayload, 0, 1)
+					} else {
+						out.Inputs = []TickInputPayload{}
+					}
+				} else {
+					out.Inputs = (out.Inputs)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v6 TickInputPayload
+					(v6).UnmarshalEasyJSON(in)
+					out.Inputs = append(out.Inputs, v6)
+					in.WantComma()
+				}
+				in.Delim(']')
 			}
 		default:
 			in.SkipRecursive()
@@ -399,73 +671,78 @@ func easyjson6a93d021DecodeTest3(in *jlexer.Lexer, out *Response) {
 		in.Consumed()
 	}
 }
-func easyjson6a93d021EncodeTest3(out *jwriter.Writer, in Response) {
+func easyjson6a93d021EncodeTest4(out *jwriter.Writer, in TickPayload) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"type\":"
+		const prefix string = ",\"room\":"
 		if first {
 			first = false
 			out.RawString(prefix[1:])
 		} else {
 			out.RawString(prefix)
 		}
-		out.String(string(in.Type))
+		out.String(string(in.Room))
 	}
 	{
-		const prefix string = ",\"status\":"
+		const prefix string = ",\"tick\":"
 		if first {
 			first = false
 			out.RawString(prefix[1:])
 		} else {
 			out.RawString(prefix)
 		}
-		out.String(string(in.Status))
+		out.Int(int(in.Tick))
 	}
-	if in.Payload != nil {
-		const prefix string = ",\"payload\":"
+	{
+		const prefix string = ",\"inputs\":"
 		if first {
 			first = false
 			out.RawString(prefix[1:])
 		} else {
 			out.RawString(prefix)
 		}
-		if m, ok := in.Payload.(easyjson.Marshaler); ok {
-			m.MarshalEasyJSON(out)
-		} else if m, ok := in.Payload.(json.Marshaler); ok {
-			out.Raw(m.MarshalJSON())
+		if in.Inputs == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
 		} else {
-			out.Raw(json.Marshal(in.Payload))
+			out.RawByte('[')
+			for v7, v8 := range in.Inputs {
+				if v7 > 0 {
+					out.RawByte(',')
+				}
+				(v8).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
 		}
 	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v Response) MarshalJSON() ([]byte, error) {
+func (v TickPayload) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson6a93d021EncodeTest3(&w, v)
+	easyjson6a93d021EncodeTest4(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v Response) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson6a93d021EncodeTest3(w, v)
+func (v TickPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest4(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *Response) UnmarshalJSON(data []byte) error {
+func (v *TickPayload) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson6a93d021DecodeTest3(&r, v)
+	easyjson6a93d021DecodeTest4(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *Response) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson6a93d021DecodeTest3(l, v)
+func (v *TickPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest4(l, v)
 }
-func easyjson6a93d021DecodeTest4(in *jlexer.Lexer, out *LeaderboardRequest) {
+func easyjson6a93d021DecodeTest5(in *jlexer.Lexer, out *TickInputPayload) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -484,10 +761,12 @@ func easyjson6a93d021DecodeTest4(in *jlexer.Lexer, out *LeaderboardRequest) {
 			continue
 		}
 		switch key {
-		case "count":
-			out.Count = int(in.Int())
-		case "page":
-			out.Page = int(in.Int())
+		case "player":
+			out.Player = string(in.String())
+		case "data":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Data).UnmarshalJSON(data))
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -498,57 +777,1610 @@ func easyjson6a93d021DecodeTest4(in *jlexer.Lexer, out *LeaderboardRequest) {
 		in.Consumed()
 	}
 }
-func easyjson6a93d021EncodeTest4(out *jwriter.Writer, in LeaderboardRequest) {
+func easyjson6a93d021EncodeTest5(out *jwriter.Writer, in TickInputPayload) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"count\":"
+		const prefix string = ",\"player\":"
 		if first {
 			first = false
 			out.RawString(prefix[1:])
 		} else {
 			out.RawString(prefix)
 		}
-		out.Int(int(in.Count))
+		out.String(string(in.Player))
 	}
 	{
-		const prefix string = ",\"page\":"
+		const prefix string = ",\"data\":"
 		if first {
 			first = false
 			out.RawString(prefix[1:])
 		} else {
 			out.RawString(prefix)
 		}
-		out.Int(int(in.Page))
+		out.Raw((in.Data).MarshalJSON())
 	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v LeaderboardRequest) MarshalJSON() ([]byte, error) {
+func (v TickInputPayload) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson6a93d021EncodeTest4(&w, v)
+	easyjson6a93d021EncodeTest5(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v TickInputPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest5(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *TickInputPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest5(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *TickInputPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest5(l, v)
+}
+func easyjson6a93d021DecodeTest6(in *jlexer.Lexer, out *SessionsPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "sessions":
+			if in.IsNull() {
+				in.Skip()
+				out.Sessions = nil
+			} else {
+				in.Delim('[')
+				if out.Sessions == nil {
+					if !in.IsDelim(']') {
+						out.Sessions = make([]SessionPayload, 0, 1)
+					} else {
+						out.Sessions = []SessionPayload{}
+					}
+				} else {
+					out.Sessions = (out.Sessions)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v9 SessionPayload
+					(v9).UnmarshalEasyJSON(in)
+					out.Sessions = append(out.Sessions, v9)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest6(out *jwriter.Writer, in SessionsPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"sessions\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		if in.Sessions == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v10, v11 := range in.Sessions {
+				if v10 > 0 {
+					out.RawByte(',')
+				}
+				(v11).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v SessionsPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest6(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v SessionsPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest6(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *SessionsPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest6(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *SessionsPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest6(l, v)
+}
+func easyjson6a93d021DecodeTest7(in *jlexer.Lexer, out *SessionPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "user_agent":
+			out.UserAgent = string(in.String())
+		case "current":
+			out.Current = bool(in.Bool())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest7(out *jwriter.Writer, in SessionPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.ID))
+	}
+	if in.UserAgent != "" {
+		const prefix string = ",\"user_agent\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.UserAgent))
+	}
+	if in.Current {
+		const prefix string = ",\"current\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.Current))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v SessionPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest7(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v SessionPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest7(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *SessionPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest7(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *SessionPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest7(l, v)
+}
+func easyjson6a93d021DecodeTest8(in *jlexer.Lexer, out *ScoreRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "token":
+			out.Token = string(in.String())
+		case "score":
+			out.Score = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest8(out *jwriter.Writer, in ScoreRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"token\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Token))
+	}
+	{
+		const prefix string = ",\"score\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Score))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ScoreRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest8(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ScoreRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest8(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ScoreRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest8(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ScoreRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest8(l, v)
+}
+func easyjson6a93d021DecodeTest9(in *jlexer.Lexer, out *ScoreEventPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "delta":
+			out.Delta = int(in.Int())
+		case "match":
+			out.Match = string(in.String())
+		case "source":
+			out.Source = string(in.String())
+		case "time":
+			out.Time = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest9(out *jwriter.Writer, in ScoreEventPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"delta\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Delta))
+	}
+	if in.Match != "" {
+		const prefix string = ",\"match\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Match))
+	}
+	{
+		const prefix string = ",\"source\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Source))
+	}
+	{
+		const prefix string = ",\"time\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Time))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ScoreEventPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest9(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ScoreEventPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest9(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ScoreEventPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest9(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ScoreEventPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest9(l, v)
+}
+func easyjson6a93d021DecodeTest10(in *jlexer.Lexer, out *RoomPlayerPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "login":
+			out.Login = string(in.String())
+		case "name":
+			out.Name = string(in.String())
+		case "connected":
+			out.Connected = bool(in.Bool())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest10(out *jwriter.Writer, in RoomPlayerPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"login\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Login))
+	}
+	{
+		const prefix string = ",\"name\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Name))
+	}
+	{
+		const prefix string = ",\"connected\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.Connected))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RoomPlayerPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest10(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RoomPlayerPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest10(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RoomPlayerPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest10(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RoomPlayerPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest10(l, v)
+}
+func easyjson6a93d021DecodeTest11(in *jlexer.Lexer, out *RoomPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "state":
+			out.State = string(in.String())
+		case "size":
+			out.Size = int(in.Int())
+		case "players":
+			if in.IsNull() {
+				in.Skip()
+				out.Players = nil
+			} else {
+				in.Delim('[')
+				if out.Players == nil {
+					if !in.IsDelim(']') {
+						out.Players = make([]RoomPlayerPayload, 0, 1)
+					} else {
+						out.Players = []RoomPlayerPayload{}
+					}
+				} else {
+					out.Players = (out.Players)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v12 RoomPlayerPayload
+					(v12).UnmarshalEasyJSON(in)
+					out.Players = append(out.Players, v12)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest11(out *jwriter.Writer, in RoomPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.ID))
+	}
+	{
+		const prefix string = ",\"state\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.State))
+	}
+	{
+		const prefix string = ",\"size\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Size))
+	}
+	{
+		const prefix string = ",\"players\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		if in.Players == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v13, v14 := range in.Players {
+				if v13 > 0 {
+					out.RawByte(',')
+				}
+				(v14).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RoomPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest11(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RoomPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest11(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RoomPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest11(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RoomPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest11(l, v)
+}
+func easyjson6a93d021DecodeTest12(in *jlexer.Lexer, out *Response) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "type":
+			out.Type = string(in.String())
+		case "status":
+			out.Status = string(in.String())
+		case "payload":
+			if m, ok := out.Payload.(easyjson.Unmarshaler); ok {
+				m.UnmarshalEasyJSON(in)
+			} else if m, ok := out.Payload.(json.Unmarshaler); ok {
+				_ = m.UnmarshalJSON(in.Raw())
+			} else {
+				out.Payload = in.Interface()
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest12(out *jwriter.Writer, in Response) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"type\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Type))
+	}
+	{
+		const prefix string = ",\"status\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Status))
+	}
+	if in.Payload != nil {
+		const prefix string = ",\"payload\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		if m, ok := in.Payload.(easyjson.Marshaler); ok {
+			m.MarshalEasyJSON(out)
+		} else if m, ok := in.Payload.(json.Marshaler); ok {
+			out.Raw(m.MarshalJSON())
+		} else {
+			out.Raw(json.Marshal(in.Payload))
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Response) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest12(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Response) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest12(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Response) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest12(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Response) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest12(l, v)
+}
+func easyjson6a93d021DecodeTest13(in *jlexer.Lexer, out *RatingPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "rating":
+			out.Rating = float64(in.Float64())
+		case "deviation":
+			out.Deviation = float64(in.Float64())
+		case "volatility":
+			out.Volatility = float64(in.Float64())
+		case "conservative":
+			out.Conservative = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest13(out *jwriter.Writer, in RatingPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"rating\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Float64(float64(in.Rating))
+	}
+	{
+		const prefix string = ",\"deviation\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Float64(float64(in.Deviation))
+	}
+	{
+		const prefix string = ",\"volatility\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Float64(float64(in.Volatility))
+	}
+	{
+		const prefix string = ",\"conservative\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Conservative))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RatingPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest13(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RatingPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest13(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RatingPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest13(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RatingPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest13(l, v)
+}
+func easyjson6a93d021DecodeTest14(in *jlexer.Lexer, out *MatchmakingPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "status":
+			out.Status = string(in.String())
+		case "since":
+			out.Since = string(in.String())
+		case "window":
+			out.Window = int(in.Int())
+		case "room":
+			out.Room = string(in.String())
+		case "opponent":
+			out.Opponent = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest14(out *jwriter.Writer, in MatchmakingPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"status\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Status))
+	}
+	if in.Since != "" {
+		const prefix string = ",\"since\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Since))
+	}
+	if in.Window != 0 {
+		const prefix string = ",\"window\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Window))
+	}
+	if in.Room != "" {
+		const prefix string = ",\"room\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Room))
+	}
+	if in.Opponent != "" {
+		const prefix string = ",\"opponent\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Opponent))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v MatchmakingPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest14(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v MatchmakingPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest14(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *MatchmakingPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest14(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *MatchmakingPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest14(l, v)
+}
+func easyjson6a93d021DecodeTest15(in *jlexer.Lexer, out *MatchRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "match":
+			out.Match = string(in.String())
+		case "opponent":
+			out.Opponent = string(in.String())
+		case "result":
+			out.Result = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest15(out *jwriter.Writer, in MatchRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"match\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Match))
+	}
+	{
+		const prefix string = ",\"opponent\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Opponent))
+	}
+	{
+		const prefix string = ",\"result\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Result))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v MatchRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest15(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v MatchRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest15(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *MatchRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest15(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *MatchRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest15(l, v)
+}
+func easyjson6a93d021DecodeTest16(in *jlexer.Lexer, out *MatchPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "status":
+			out.Status = string(in.String())
+		case "rating":
+			if in.IsNull() {
+				in.Skip()
+				out.Rating = nil
+			} else {
+				if out.Rating == nil {
+					out.Rating = new(RatingPayload)
+				}
+				(*out.Rating).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest16(out *jwriter.Writer, in MatchPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"status\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Status))
+	}
+	if in.Rating != nil {
+		const prefix string = ",\"rating\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		(*in.Rating).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v MatchPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest16(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v MatchPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest16(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *MatchPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest16(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *MatchPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest16(l, v)
+}
+func easyjson6a93d021DecodeTest17(in *jlexer.Lexer, out *LeaderboardRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "period":
+			out.Period = string(in.String())
+		case "count":
+			out.Count = int(in.Int())
+		case "page":
+			out.Page = int(in.Int())
+		case "cursor":
+			out.Cursor = string(in.String())
+		case "date":
+			out.Date = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest17(out *jwriter.Writer, in LeaderboardRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"period\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Period))
+	}
+	{
+		const prefix string = ",\"count\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Count))
+	}
+	{
+		const prefix string = ",\"page\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Page))
+	}
+	{
+		const prefix string = ",\"cursor\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Cursor))
+	}
+	{
+		const prefix string = ",\"date\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Date))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v LeaderboardRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest17(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v LeaderboardRequest) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson6a93d021EncodeTest4(w, v)
+	easyjson6a93d021EncodeTest17(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *LeaderboardRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest17(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *LeaderboardRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest17(l, v)
+}
+func easyjson6a93d021DecodeTest18(in *jlexer.Lexer, out *HistoryPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "events":
+			if in.IsNull() {
+				in.Skip()
+				out.Events = nil
+			} else {
+				in.Delim('[')
+				if out.Events == nil {
+					if !in.IsDelim(']') {
+						out.Events = make([]ScoreEventPayload, 0, 1)
+					} else {
+						out.Events = []ScoreEventPayload{}
+					}
+				} else {
+					out.Events = (out.Events)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v15 ScoreEventPayload
+					(v15).UnmarshalEasyJSON(in)
+					out.Events = append(out.Events, v15)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "next":
+			out.Next = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest18(out *jwriter.Writer, in HistoryPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"events\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		if in.Events == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v16, v17 := range in.Events {
+				if v16 > 0 {
+					out.RawByte(',')
+				}
+				(v17).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Next != "" {
+		const prefix string = ",\"next\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Next))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v HistoryPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest18(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v HistoryPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest18(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *LeaderboardRequest) UnmarshalJSON(data []byte) error {
+func (v *HistoryPayload) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson6a93d021DecodeTest4(&r, v)
+	easyjson6a93d021DecodeTest18(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *LeaderboardRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson6a93d021DecodeTest4(l, v)
+func (v *HistoryPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest18(l, v)
+}
+func easyjson6a93d021DecodeTest19(in *jlexer.Lexer, out *GamePayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "token":
+			out.Token = string(in.String())
+		case "max_duration":
+			out.MaxDuration = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest19(out *jwriter.Writer, in GamePayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"token\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Token))
+	}
+	{
+		const prefix string = ",\"max_duration\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.MaxDuration))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v GamePayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest19(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v GamePayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest19(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *GamePayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest19(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *GamePayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest19(l, v)
+}
+func easyjson6a93d021DecodeTest20(in *jlexer.Lexer, out *FieldErrorPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "field":
+			out.Field = string(in.String())
+		case "code":
+			out.Code = string(in.String())
+		case "message":
+			out.Message = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest20(out *jwriter.Writer, in FieldErrorPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"field\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Field))
+	}
+	{
+		const prefix string = ",\"code\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Code))
+	}
+	{
+		const prefix string = ",\"message\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Message))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v FieldErrorPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest20(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v FieldErrorPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest20(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *FieldErrorPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest20(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *FieldErrorPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest20(l, v)
 }
-func easyjson6a93d021DecodeTest5(in *jlexer.Lexer, out *ErrorPayload) {
+func easyjson6a93d021DecodeTest21(in *jlexer.Lexer, out *ErrorPayload) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -571,6 +2403,31 @@ func easyjson6a93d021DecodeTest5(in *jlexer.Lexer, out *ErrorPayload) {
 			out.Message = string(in.String())
 		case "field":
 			out.Field = string(in.String())
+		case "code":
+			out.Code = string(in.String())
+		case "errors":
+			if in.IsNull() {
+				in.Skip()
+				out.Errors = nil
+			} else {
+				in.Delim('[')
+				if out.Errors == nil {
+					if !in.IsDelim(']') {
+						out.Errors = make([]FieldErrorPayload, 0, 1)
+					} else {
+						out.Errors = []FieldErrorPayload{}
+					}
+				} else {
+					out.Errors = (out.Errors)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v18 FieldErrorPayload
+					(v18).UnmarshalEasyJSON(in)
+					out.Errors = append(out.Errors, v18)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -581,7 +2438,7 @@ func easyjson6a93d021DecodeTest5(in *jlexer.Lexer, out *ErrorPayload) {
 		in.Consumed()
 	}
 }
-func easyjson6a93d021EncodeTest5(out *jwriter.Writer, in ErrorPayload) {
+func easyjson6a93d021EncodeTest21(out *jwriter.Writer, in ErrorPayload) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -605,29 +2462,129 @@ func easyjson6a93d021EncodeTest5(out *jwriter.Writer, in ErrorPayload) {
 		}
 		out.String(string(in.Field))
 	}
+	if in.Code != "" {
+		const prefix string = ",\"code\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Code))
+	}
+	if len(in.Errors) != 0 {
+		const prefix string = ",\"errors\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v19, v20 := range in.Errors {
+				if v19 > 0 {
+					out.RawByte(',')
+				}
+				(v20).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v ErrorPayload) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson6a93d021EncodeTest5(&w, v)
+	easyjson6a93d021EncodeTest21(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v ErrorPayload) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson6a93d021EncodeTest5(w, v)
+	easyjson6a93d021EncodeTest21(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *ErrorPayload) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson6a93d021DecodeTest5(&r, v)
+	easyjson6a93d021DecodeTest21(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *ErrorPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson6a93d021DecodeTest5(l, v)
+	easyjson6a93d021DecodeTest21(l, v)
+}
+func easyjson6a93d021DecodeTest22(in *jlexer.Lexer, out *CSRFPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "token":
+			out.Token = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6a93d021EncodeTest22(out *jwriter.Writer, in CSRFPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"token\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Token))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v CSRFPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson6a93d021EncodeTest22(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v CSRFPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6a93d021EncodeTest22(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *CSRFPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson6a93d021DecodeTest22(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *CSRFPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6a93d021DecodeTest22(l, v)
 }
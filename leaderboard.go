@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	leaderboardPageSize = 10
+	maxLeaderboardCount = 100
+
+	defaultLeaderboardNeighbours = 5
+	maxLeaderboardNeighbours     = 50
+)
+
+// LeaderboardCursor remembers score and uuid of the user a page ended
+// (or started, if it is Backward) at. Paging by cursor does not skip or
+// repeat users when scores change between requests, unlike paging by number.
+type LeaderboardCursor struct {
+	Score    int
+	UUID     uint32
+	Backward bool
+}
+
+// String encodes cursor, clients get it as an opaque string
+func (cursor LeaderboardCursor) String() string {
+	direction := "next"
+	if cursor.Backward {
+		direction = "prev"
+	}
+	raw := fmt.Sprintf("%s:%d:%d", direction, cursor.Score, cursor.UUID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseLeaderboardCursor(value string) (LeaderboardCursor, error) {
+	cursor := LeaderboardCursor{}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, &ValidationError{[]FieldError{{"cursor", "invalid", "invalid cursor"}}}
+	}
+	var direction string
+	var score int
+	var uuid uint32
+	_, err = fmt.Sscanf(string(raw), "%4s:%d:%d", &direction, &score, &uuid)
+	if err != nil || (direction != "next" && direction != "prev") {
+		return cursor, &ValidationError{[]FieldError{{"cursor", "invalid", "invalid cursor"}}}
+	}
+	return LeaderboardCursor{score, uuid, direction == "prev"}, nil
+}
+
+// LeaderboardPage is a part of leaderboard, Offset is 0-based position of its first user.
+// Period is nil for the all-time leaderboard. Key is what users are ranked by, score if nil.
+type LeaderboardPage struct {
+	Users  []User
+	Offset int
+	Total  int
+	Period *Period
+	Key    func(user *User) int
+}
+
+func (page *LeaderboardPage) key(user *User) int {
+	if page.Key == nil {
+		return user.score
+	}
+	return page.Key(user)
+}
+
+// Next is cursor of the page after this one, empty if this page is the last
+func (page *LeaderboardPage) Next() string {
+	if len(page.Users) == 0 || page.Offset+len(page.Users) >= page.Total {
+		return ""
+	}
+	last := page.Users[len(page.Users)-1]
+	return LeaderboardCursor{page.key(&last), last.uuid, false}.String()
+}
+
+// Prev is cursor of the page before this one, empty if this page is the first
+func (page *LeaderboardPage) Prev() string {
+	if len(page.Users) == 0 || page.Offset == 0 {
+		return ""
+	}
+	first := page.Users[0]
+	return LeaderboardCursor{page.key(&first), first.uuid, true}.String()
+}
+
+// GetLeaderboardPage returns page by cursor if request has one, by page number otherwise.
+// Count defaults to leaderboardPageSize and may not exceed maxLeaderboardCount.
+func GetLeaderboardPage(request LeaderboardRequest) (*LeaderboardPage, error) {
+	count := request.Count
+	if count == 0 {
+		count = leaderboardPageSize
+	}
+	if count < 1 || count > maxLeaderboardCount {
+		return nil, &ValidationError{[]FieldError{{"count", "out_of_range",
+			"count must be from 1 to " + strconv.Itoa(maxLeaderboardCount)}}}
+	}
+	standings, period, err := getStandings(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var userSlice []User
+	var offset, total int
+	if request.Cursor == "" {
+		page := request.Page
+		if page == 0 {
+			page = 1
+		}
+		if page < 1 {
+			return nil, &ValidationError{[]FieldError{{"page", "too_small", "invalid page number"}}}
+		}
+		offset = count * (page - 1)
+		total, _ = standings.Count()
+		// the first page of a period nobody has played in yet is just empty
+		if offset >= total && page > 1 {
+			return nil, notFound("not enough users")
+		}
+		userSlice = standings.Page(offset, count)
+	} else {
+		cursor, err := ParseLeaderboardCursor(request.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// login breaks ties, it never changes so it is not in the cursor.
+		// If the user is gone, the page starts with the first user of that score.
+		key := User{uuid: cursor.UUID, score: cursor.Score}
+		if user, err := userStore.Get(cursor.UUID); err == nil {
+			key.login = user.login
+		}
+		userSlice, offset, total = standings.Seek(key, count, cursor.Backward)
+	}
+
+	if period != nil {
+		userSlice = withProfiles(userSlice)
+	}
+	page := &LeaderboardPage{Users: userSlice, Offset: offset, Total: total, Period: period}
+	if request.Period == "rating" {
+		page.Key = ratingKey
+	}
+	return page, nil
+}
+
+// getStandings picks leaderboard of request period, the current one
+// or the one of request date if it is set. Rating leaderboard is all-time
+// and ranks by conservative rating.
+func getStandings(request LeaderboardRequest) (Standings, *Period, error) {
+	switch request.Period {
+	case "", "all", "rating":
+		if request.Date != "" {
+			return nil, nil, &ValidationError{[]FieldError{{"date", "not_applicable", "all-time leaderboard has no periods"}}}
+		}
+		if request.Period == "rating" {
+			return leaderboard.Ratings(), nil, nil
+		}
+		return leaderboard, nil, nil
+	}
+	board, ok := periodLeaderboards[request.Period]
+	if !ok {
+		return nil, nil, notFound("unknown period")
+	}
+
+	at := timeNow()
+	if request.Date != "" {
+		day, err := time.Parse("2006-01-02", request.Date)
+		if err != nil {
+			return nil, nil, &ValidationError{[]FieldError{{"date", "invalid", "date must look like 2019-04-01"}}}
+		}
+		at = day.Add(periodConfig.DayStart)
+	}
+	standings, period, err := board.Standings(at)
+	if err != nil {
+		return nil, nil, err
+	}
+	return standings, &period, nil
+}
+
+// GetLeaderboardAround returns page with user in the middle of it and user rank
+func GetLeaderboardAround(user *User, neighbours int) (*LeaderboardPage, int, error) {
+	userSlice, offset, total, rank := leaderboard.Around(user.uuid, neighbours)
+	if rank == 0 {
+		return nil, 0, notFound("user is not in leaderboard")
+	}
+	return &LeaderboardPage{Users: userSlice, Offset: offset, Total: total}, rank, nil
+}
+
+// getLeaderboardRequest reads period and page number from the path and count, cursor and date from the query
+func getLeaderboardRequest(r *http.Request) (LeaderboardRequest, error) {
+	request := LeaderboardRequest{
+		Period: mux.Vars(r)["period"],
+		Cursor: r.URL.Query().Get("cursor"),
+		Date:   r.URL.Query().Get("date"),
+	}
+	var err error
+	if page, ok := mux.Vars(r)["page"]; ok {
+		request.Page, err = strconv.Atoi(page)
+		if err != nil {
+			return request, badRequest(err)
+		}
+		if request.Page < 1 {
+			return request, &ValidationError{[]FieldError{{"page", "too_small", "invalid page number"}}}
+		}
+	}
+	if count := r.URL.Query().Get("count"); count != "" {
+		request.Count, err = strconv.Atoi(count)
+		if err != nil || request.Count == 0 {
+			return request, &ValidationError{[]FieldError{{"count", "out_of_range",
+				"count must be from 1 to " + strconv.Itoa(maxLeaderboardCount)}}}
+		}
+	}
+	return request, nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// LeaderboardPeriodConfig sets where leaderboard periods begin, all in UTC.
+// Days begin DayStart after midnight, weeks begin on WeekStart
+// and months on their first day.
+type LeaderboardPeriodConfig struct {
+	DayStart  time.Duration
+	WeekStart time.Weekday
+	// Archived is how many past periods of each kind keep their final standings
+	Archived int
+}
+
+var periodConfig = LeaderboardPeriodConfig{
+	DayStart:  0,
+	WeekStart: time.Monday,
+	Archived:  30,
+}
+
+var leaderboardPeriods = []string{"daily", "weekly", "monthly"}
+
+// periodLeaderboards are set up in InitStores, see ReplayPeriodLeaderboards
+var periodLeaderboards map[string]*PeriodLeaderboard
+
+// Standings are users ranked by descending score: the all-time leaderboard,
+// the current period of a period leaderboard or an archived one
+type Standings interface {
+	Count() (int, error)
+	Page(offset int, count int) []User
+	Seek(key User, count int, backward bool) ([]User, int, int)
+}
+
+// Period is one day, week or month, End is the Start of the next one
+type Period struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// NewPeriod returns period of kind name that t belongs to
+func NewPeriod(name string, t time.Time) Period {
+	t = t.UTC().Add(-periodConfig.DayStart)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	var end time.Time
+	switch name {
+	case "weekly":
+		start = start.AddDate(0, 0, -(int(start.Weekday())-int(periodConfig.WeekStart)+7)%7)
+		end = start.AddDate(0, 0, 7)
+	case "monthly":
+		start = start.AddDate(0, 0, 1-start.Day())
+		end = start.AddDate(0, 1, 0)
+	default:
+		end = start.AddDate(0, 0, 1)
+	}
+	return Period{name, start.Add(periodConfig.DayStart), end.Add(periodConfig.DayStart)}
+}
+
+func (period Period) Contains(t time.Time) bool {
+	return !t.Before(period.Start) && t.Before(period.End)
+}
+
+// ArchivedStandings are final standings of a past period, they never change
+type ArchivedStandings struct {
+	Period Period
+	index  *RankIndex
+}
+
+func (standings *ArchivedStandings) Count() (int, error) {
+	return standings.index.Len(), nil
+}
+
+func (standings *ArchivedStandings) Page(offset int, count int) []User {
+	return standings.index.Range(offset, count)
+}
+
+func (standings *ArchivedStandings) Seek(key User, count int, backward bool) ([]User, int, int) {
+	userSlice, offset := standings.index.Seek(key, count, backward)
+	return userSlice, offset, standings.index.Len()
+}
+
+// PeriodLeaderboard ranks users by score earned during the current period.
+// When the period is over its standings are archived and a new period
+// starts empty, this happens on the first use after the boundary.
+// Standings are kept in memory, users in them have only uuid, login and score.
+type PeriodLeaderboard struct {
+	mu      sync.Mutex
+	period  Period
+	index   *RankIndex
+	archive []*ArchivedStandings // oldest first
+}
+
+func NewPeriodLeaderboard(name string, now time.Time) *PeriodLeaderboard {
+	return &PeriodLeaderboard{
+		period: NewPeriod(name, now),
+		index:  NewRankIndex(),
+	}
+}
+
+// roll archives the current period and starts the one now belongs to, if it is time
+func (board *PeriodLeaderboard) roll(now time.Time) {
+	if now.Before(board.period.End) {
+		return
+	}
+	if board.index.Len() != 0 {
+		board.archive = append(board.archive, &ArchivedStandings{board.period, board.index})
+		if len(board.archive) > periodConfig.Archived {
+			board.archive = board.archive[len(board.archive)-periodConfig.Archived:]
+		}
+	}
+	board.period = NewPeriod(board.period.Name, now)
+	board.index = NewRankIndex()
+}
+
+// Add adds delta to score user has in the period of at.
+// Scores that come after their period is archived are dropped.
+func (board *PeriodLeaderboard) Add(user *User, delta int, at time.Time) {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	board.roll(at)
+	if at.Before(board.period.Start) {
+		return
+	}
+	entry, _ := board.index.Get(user.uuid)
+	board.index.Set(User{uuid: user.uuid, login: user.login, score: entry.score + delta})
+}
+
+func (board *PeriodLeaderboard) Count() (int, error) {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	board.roll(timeNow())
+	return board.index.Len(), nil
+}
+
+func (board *PeriodLeaderboard) Page(offset int, count int) []User {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	board.roll(timeNow())
+	return board.index.Range(offset, count)
+}
+
+func (board *PeriodLeaderboard) Seek(key User, count int, backward bool) ([]User, int, int) {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	board.roll(timeNow())
+	userSlice, offset := board.index.Seek(key, count, backward)
+	return userSlice, offset, board.index.Len()
+}
+
+// Standings returns standings of the period t belongs to, either the current or an archived one
+func (board *PeriodLeaderboard) Standings(t time.Time) (Standings, Period, error) {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	board.roll(timeNow())
+	if board.period.Contains(t) {
+		return board, board.period, nil
+	}
+	for _, standings := range board.archive {
+		if standings.Period.Contains(t) {
+			return standings, standings.Period, nil
+		}
+	}
+	return nil, Period{}, notFound("no standings for that period")
+}
+
+func NewPeriodLeaderboards(now time.Time) map[string]*PeriodLeaderboard {
+	boards := make(map[string]*PeriodLeaderboard, len(leaderboardPeriods))
+	for _, name := range leaderboardPeriods {
+		boards[name] = NewPeriodLeaderboard(name, now)
+	}
+	return boards
+}
+
+// RecordScore adds score user earned at some moment to every period leaderboard
+func RecordScore(user *User, delta int, at time.Time) {
+	for _, board := range periodLeaderboards {
+		board.Add(user, delta, at)
+	}
+}
+
+// ReplayPeriodLeaderboards rebuilds period leaderboards from game scores in the log,
+// going as far back as the oldest period that may still be archived
+func ReplayPeriodLeaderboards(now time.Time) error {
+	since := NewPeriod("monthly", now).Start.AddDate(0, -periodConfig.Archived, 0)
+	periodLeaderboards = NewPeriodLeaderboards(since)
+	eventSlice, err := scoreEventStore.Since(since)
+	if err != nil {
+		return err
+	}
+
+	users := make(map[uint32]*User)
+	for _, event := range eventSlice {
+		if event.source != ScoreSourceGame {
+			continue
+		}
+		user, ok := users[event.user]
+		if !ok {
+			user, err = userStore.Get(event.user)
+			if err == ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			users[event.user] = user
+		}
+		RecordScore(user, event.delta, event.at)
+	}
+	return nil
+}
+
+// withProfiles replaces period leaderboard entries with current users, keeping period scores
+func withProfiles(userSlice []User) []User {
+	for i, entry := range userSlice {
+		if user, err := userStore.Get(entry.uuid); err == nil {
+			user.score = entry.score
+			userSlice[i] = *user
+		}
+	}
+	return userSlice
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MatchmakingConfig describes how close opponents have to be. A player
+// accepts opponents whose conservative rating differs by at most
+// the window, it starts at InitialWindow and grows by WindowGrowth
+// every WidenEvery the player waits, up to MaxWindow.
+type MatchmakingConfig struct {
+	InitialWindow int
+	WindowGrowth  int
+	WidenEvery    time.Duration
+	MaxWindow     int
+	// Interval is how often waiting players are paired again as their windows grow
+	Interval time.Duration
+	// JoinTimeout is how long a room waits for the paired players to join
+	JoinTimeout time.Duration
+}
+
+var matchmakingConfig = MatchmakingConfig{
+	InitialWindow: 100,
+	WindowGrowth:  50,
+	WidenEvery:    5 * time.Second,
+	MaxWindow:     1000,
+	Interval:      time.Second,
+	JoinTimeout:   30 * time.Second,
+}
+
+var (
+	ErrAlreadyQueued = &GameError{"already_queued", "", "you are already looking for a match"}
+	ErrNotQueued     = &GameError{"not_queued", "", "you are not looking for a match"}
+)
+
+type queueEntry struct {
+	uuid   uint32
+	login  string
+	rating int // conservative rating
+	joined time.Time
+}
+
+// Pairing is two players the queue matched, they play in room.
+// The room id is also the match players report results of, it is
+// issued when they are paired and outlives the pairing itself.
+type Pairing struct {
+	room    string
+	players [2]queueEntry
+	at      time.Time
+}
+
+// Opponent returns the player uuid is paired with
+func (pairing *Pairing) Opponent(uuid uint32) queueEntry {
+	if pairing.players[0].uuid == uuid {
+		return pairing.players[1]
+	}
+	return pairing.players[0]
+}
+
+// MatchQueue keeps players looking for a match in the order they came.
+// Time comes from clock only, so tests can move it as they like.
+type MatchQueue struct {
+	config   MatchmakingConfig
+	clock    func() time.Time
+	mu       sync.Mutex
+	entries  []*queueEntry
+	pairings map[uint32]*Pairing // paired players until they queue again or JoinTimeout passes
+}
+
+func NewMatchQueue(config MatchmakingConfig, clock func() time.Time) *MatchQueue {
+	return &MatchQueue{
+		config:   config,
+		clock:    clock,
+		pairings: make(map[uint32]*Pairing),
+	}
+}
+
+// timeNow is looked up on every call, tests replace it
+var matchQueue = NewMatchQueue(matchmakingConfig, func() time.Time { return timeNow() })
+
+// window returns rating difference entry accepts at now
+func (queue *MatchQueue) window(entry *queueEntry, now time.Time) int {
+	window := queue.config.InitialWindow
+	if queue.config.WidenEvery > 0 {
+		window += queue.config.WindowGrowth * int(now.Sub(entry.joined)/queue.config.WidenEvery)
+	}
+	if window > queue.config.MaxWindow {
+		window = queue.config.MaxWindow
+	}
+	return window
+}
+
+func (queue *MatchQueue) find(uuid uint32) int {
+	for i, entry := range queue.entries {
+		if entry.uuid == uuid {
+			return i
+		}
+	}
+	return -1
+}
+
+// Enqueue puts user at the end of the queue, the pairing user had before is forgotten
+func (queue *MatchQueue) Enqueue(user *User) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if queue.find(user.uuid) != -1 {
+		return ErrAlreadyQueued
+	}
+	delete(queue.pairings, user.uuid)
+	queue.entries = append(queue.entries, &queueEntry{
+		uuid:   user.uuid,
+		login:  user.login,
+		rating: user.rating.Conservative(),
+		joined: queue.clock(),
+	})
+	return nil
+}
+
+// Dequeue takes player out of the queue or forgets their pairing,
+// it returns false if there was neither
+func (queue *MatchQueue) Dequeue(uuid uint32) bool {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	_, paired := queue.pairings[uuid]
+	delete(queue.pairings, uuid)
+	i := queue.find(uuid)
+	if i == -1 {
+		return paired
+	}
+	queue.entries = append(queue.entries[:i], queue.entries[i+1:]...)
+	return true
+}
+
+// Lookup returns where player is: waiting with the current window, paired, or neither
+func (queue *MatchQueue) Lookup(uuid uint32) (entry *queueEntry, window int, pairing *Pairing) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	now := queue.clock()
+	if i := queue.find(uuid); i != -1 {
+		entry := *queue.entries[i]
+		return &entry, queue.window(&entry, now), nil
+	}
+	pairing, ok := queue.pairings[uuid]
+	if !ok || now.Sub(pairing.at) > queue.config.JoinTimeout {
+		return nil, 0, nil
+	}
+	return nil, 0, pairing
+}
+
+func (queue *MatchQueue) Len() int {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return len(queue.entries)
+}
+
+// Match pairs waiting players and returns the new pairings.
+// Players who waited longer choose first, each takes the closest
+// rated player among those who came later. The difference has to fit
+// in the windows of both, so a newcomer is not paired beyond its own window.
+func (queue *MatchQueue) Match() []*Pairing {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	now := queue.clock()
+	for player, pairing := range queue.pairings {
+		if now.Sub(pairing.at) > queue.config.JoinTimeout {
+			delete(queue.pairings, player)
+		}
+	}
+
+	pairingSlice := make([]*Pairing, 0)
+	paired := make(map[*queueEntry]bool)
+	for i, entry := range queue.entries {
+		if paired[entry] {
+			continue
+		}
+		window := queue.window(entry, now)
+		var opponent *queueEntry
+		for _, candidate := range queue.entries[i+1:] {
+			difference := ratingDifference(entry, candidate)
+			if !paired[candidate] && difference <= window && difference <= queue.window(candidate, now) &&
+				(opponent == nil || difference < ratingDifference(entry, opponent)) {
+				opponent = candidate
+			}
+		}
+		if opponent == nil {
+			continue
+		}
+
+		paired[entry], paired[opponent] = true, true
+		pairing := &Pairing{
+			room:    uuid.New().String(),
+			players: [2]queueEntry{*entry, *opponent},
+			at:      now,
+		}
+		queue.pairings[entry.uuid] = pairing
+		queue.pairings[opponent.uuid] = pairing
+		pairingSlice = append(pairingSlice, pairing)
+	}
+
+	waiting := queue.entries[:0]
+	for _, entry := range queue.entries {
+		if !paired[entry] {
+			waiting = append(waiting, entry)
+		}
+	}
+	queue.entries = waiting
+	return pairingSlice
+}
+
+func ratingDifference(a *queueEntry, b *queueEntry) int {
+	if a.rating > b.rating {
+		return a.rating - b.rating
+	}
+	return b.rating - a.rating
+}
+
+// EnqueueUser puts user in the matchmaking queue with the rating user has now.
+// Players already in a room have to leave it first.
+func EnqueueUser(uuid uint32) error {
+	user, err := GetUser(uuid)
+	if err != nil {
+		return err
+	}
+	if hub.RoomOf(uuid) != "" {
+		return ErrAlreadyInRoom
+	}
+	return matchQueue.Enqueue(user)
+}
+
+// Matchmake pairs waiting players, reserves rooms for them
+// and tells the ones connected over websocket where to go
+func Matchmake() {
+	for _, pairing := range matchQueue.Match() {
+		IssueMatch(pairing.room, [2]uint32{pairing.players[0].uuid, pairing.players[1].uuid}, pairing.at)
+		hub.Reserve(pairing.room, []uint32{pairing.players[0].uuid, pairing.players[1].uuid},
+			matchQueue.config.JoinTimeout)
+		for _, player := range pairing.players {
+			response := Response{
+				Type:    "matchmaking",
+				Status:  "success",
+				Payload: matchmakingPayload(player.uuid),
+			}
+			message, _ := response.MarshalJSON()
+			hub.Send(player.uuid, message)
+		}
+	}
+}
+
+// StartMatchmaker pairs players every interval until stop is called
+func StartMatchmaker(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				Matchmake()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
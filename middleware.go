@@ -1,36 +1,113 @@
 package main
 
-import "net/http"
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+)
 
+// SessionMiddleware loads session by sid cookie.
+// Requests without valid cookie get an anonymous session which is not stored
+// until handler calls renewSession, so anonymous visitors cost nothing.
+// Requests changing anything must carry CSRF token (see HandleCSRFToken)
+// in csrfHeader.
 func SessionMiddleware(next func(http.ResponseWriter, *http.Request, *Session), authRequiered bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		var session *Session
 		cookie, err := r.Cookie("sid")
+		if err == nil {
+			session, err = GetSession(cookie.Value)
+		}
 		if err != nil {
-			session := NewSession()
-			cookie = &http.Cookie{
-				Name:     "sid",
-				Value:    session.sid,
-				HttpOnly: true,
-			}
-			http.SetCookie(w, cookie)
+			session = NewSession()
 		}
-		session, ok := sessions[cookie.Value]
-		if !ok {
-			session = *NewSession()
-			cookie = &http.Cookie{
-				Name:     "sid",
-				Value:    session.sid,
-				HttpOnly: true,
-			}
-			http.SetCookie(w, cookie)
+
+		session.userAgent = r.UserAgent()
+		session.lastSeen = timeNow()
+		if !isSafeMethod(r.Method) && !checkCSRFToken(r, session) {
+			writeError(w, "csrf", ErrBadCSRFToken)
+			return
 		}
 		if authRequiered && session.user == nil {
-			w.WriteHeader(http.StatusForbidden)
-			session.Save()
+			writeError(w, "auth", ErrUnauthorized)
 			return
 		}
-		next(w, r, &session)
-		session.Save()
+		next(w, r, session)
+		// handlers save sessions they change, the session may be gone by now
+		if session.stored && !session.deleted {
+			session.Touch()
+		}
+	}
+}
+
+const (
+	csrfHeader = "X-CSRF-Token"
+	csrfCookie = "csrf"
+)
+
+// checkCSRFToken compares token in csrfHeader with the one of session.
+// Anonymous sessions are not stored, their token comes back in csrfCookie.
+func checkCSRFToken(r *http.Request, session *Session) bool {
+	token := r.Header.Get(csrfHeader)
+	if session.stored {
+		return session.CheckCSRFToken(token)
+	}
+	cookie, err := r.Cookie(csrfCookie)
+	return err == nil && cookie.Value != "" &&
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(token)) == 1
+}
+
+func isSafeMethod(method string) bool {
+	return method == "GET" || method == "HEAD" || method == "OPTIONS"
+}
 
+// CookieConfig holds attributes of the session cookie
+type CookieConfig struct {
+	Path     string
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+var cookieConfig = CookieConfig{
+	Path:     "/",
+	SameSite: http.SameSiteLaxMode,
+}
+
+func sessionCookie(value string, maxAge int, expires time.Time) *http.Cookie {
+	return newCookie("sid", value, maxAge, expires)
+}
+
+func newCookie(name string, value string, maxAge int, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     cookieConfig.Path,
+		Domain:   cookieConfig.Domain,
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   cookieConfig.Secure,
+		HttpOnly: true,
+		SameSite: cookieConfig.SameSite,
 	}
 }
+
+// renewSession gives session a fresh sid and sets its cookie.
+// It must be called whenever session gains privileges (login, registration,
+// password change) to rule out session fixation, and before anything
+// is written to w. The new CSRF token is sent in csrfHeader.
+func renewSession(w http.ResponseWriter, session *Session) error {
+	err := session.Renew()
+	if err != nil {
+		return err
+	}
+
+	expires := session.Expires()
+	http.SetCookie(w, sessionCookie(session.sid, int(expires.Sub(timeNow())/time.Second), expires))
+	w.Header().Set(csrfHeader, session.csrfToken)
+	return nil
+}
+
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, sessionCookie("", -1, time.Time{}))
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"errors"
-	"sort"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,161 +20,247 @@ type User struct {
 	name         string
 	avatar       string
 	score        int
+	rating       Rating
 }
 
 type Session struct {
-	sid  string
-	user *User
+	sid       string
+	id        string // public id, sid itself is never shown to anyone
+	user      *User
+	userAgent string
+	csrfToken string
+	created   time.Time
+	lastSeen  time.Time
+	stored    bool
+	deleted   bool
 }
 
-var users map[string]User
-var uuidUserIndex map[uint32]string
-var sessions map[string]Session
+var (
+	ErrUserExists  = errors.New("user already exists")
+	ErrNotFound    = errors.New("not found")
+	ErrBadLogin    = errors.New("incorrect login")
+	ErrBadPassword = errors.New("incorrect password")
+	// ErrBadCredentials stands for both ErrBadLogin and ErrBadPassword
+	// when it should not be told which one it was
+	ErrBadCredentials = errors.New("invalid credentials")
+)
 
-func (session *Session) Save() error {
-	sessions[session.sid] = *session
-	return nil
+// ValidationError lists every invalid field of a request
+type ValidationError struct {
+	Errors []FieldError
 }
 
-func (user *User) Save() error {
-	users[user.login] = *user
-	return nil
+func (err *ValidationError) Error() string {
+	return "invalid " + err.Errors[0].Field
 }
 
-func GetUser(uuid uint32) (*User, error) {
-	login, exists := uuidUserIndex[uuid]
-	if !exists {
-		return nil, errors.New("wrong uuid")
-	}
+// notFoundError is ErrNotFound with more specific message
+type notFoundError struct {
+	message string
+}
 
-	user, ok := users[login]
-	if !ok {
-		return nil, errors.New("uuid-login match error")
-	}
+func (err *notFoundError) Error() string {
+	return err.message
+}
 
-	return &user, nil
+func (err *notFoundError) Is(target error) bool {
+	return target == ErrNotFound
 }
 
-func GetUserByLogin(login string) (*User, error) {
-	user, exists := users[login]
-	if !exists {
-		return nil, errors.New("wrong login")
-	}
+func notFound(message string) error {
+	return &notFoundError{message}
+}
 
-	return &user, nil
+func missingField(field string) error {
+	return &ValidationError{[]FieldError{{field, "required", "missing " + field}}}
 }
 
-func GetSession(id string) (*Session, error) {
-	session, exists := sessions[id]
-	if !exists {
-		return nil, errors.New("Wrong sid")
+var userStore UserStore
+var sessionStore SessionStore
+var leaderboard *RankedUserStore
+var scoreEventStore ScoreEventStore
+
+func (session *Session) Save() error {
+	err := sessionStore.Save(session)
+	if err == nil {
+		session.stored = true
 	}
-	return &session, nil
+	return err
 }
 
-func GetUsers(count, page int) ([]User, error) {
-	if page < 1 {
-		return nil, errors.New("invalid page number")
-	}
+// Touch stores when session was last seen, unlike Save it keeps
+// everything else parallel requests may have changed
+func (session *Session) Touch() error {
+	return sessionStore.Touch(session)
+}
 
-	min := count * (page - 1)
-	if min >= len(users) {
-		return nil, errors.New("not enough users")
-	}
+func (user *User) Save() error {
+	return userStore.Save(user)
+}
 
-	//var max uint = uint(math.Max(float64(count*page), float64(len(users))))
+func GetUser(uuid uint32) (*User, error) {
+	return userStore.Get(uuid)
+}
 
-	max := count * page
-	if max > len(users) {
-		max = len(users)
-	}
+// UpdateUser applies change to the latest version of user and saves it
+func UpdateUser(uuid uint32, change func(user *User) error) (*User, error) {
+	return leaderboard.Update(uuid, change)
+}
 
-	keySlice := make([]string, 0, len(users))
-	for k := range users {
-		keySlice = append(keySlice, k)
-	}
-	sort.Strings(keySlice)
+func GetUserByLogin(login string) (*User, error) {
+	return userStore.GetByLogin(login)
+}
 
-	userSlice := make([]User, 0, len(users))
-	for _, v := range keySlice {
-		userSlice = append(userSlice, users[v])
+// GetSession returns stored session, expired sessions are deleted on the way
+func GetSession(id string) (*Session, error) {
+	session, err := sessionStore.Get(id)
+	if err != nil {
+		return nil, err
 	}
-
-	return userSlice[min:max], nil
+	if session.Expired(timeNow()) {
+		session.Delete()
+		return nil, ErrNotFound
+	}
+	session.stored = true
+	return session, nil
 }
 
 func (session *Session) Delete() error {
-	delete(sessions, session.sid)
-	return nil
+	err := sessionStore.Delete(session)
+	if err == nil {
+		session.deleted = true
+	}
+	return err
+}
+
+// GetUserSessions returns all sessions where user is logged in
+func GetUserSessions(user *User) ([]Session, error) {
+	return sessionStore.ListByUser(user.uuid)
 }
 
 func (user *User) Delete() error {
-	delete(uuidUserIndex, user.uuid)
-	delete(users, user.login)
-	return nil
+	return userStore.Delete(user)
 }
 
+// NewSession makes an anonymous session, it is not stored until saved
 func NewSession() *Session {
-	id := uuid.New().String()
-	session := Session{
-		sid:  id,
-		user: nil,
+	now := timeNow()
+	return &Session{
+		sid:      uuid.New().String(),
+		id:       uuid.New().String(),
+		user:     nil,
+		created:  now,
+		lastSeen: now,
 	}
-	sessions[id] = session
-	return &session
 }
 
 func NewUser(login string, password string, email string, name string) (*User, error) {
 	if login == "" {
-		return nil, errors.New("login")
+		return nil, missingField("login")
 	}
 
 	if password == "" {
-		return nil, errors.New("password")
+		return nil, missingField("password")
 	}
 
 	if email == "" {
-		return nil, errors.New("email")
+		return nil, missingField("email")
 	}
 
 	if name == "" {
-		return nil, errors.New("name")
+		return nil, missingField("name")
 	}
 
-	if _, ok := users[login]; ok {
-		return nil, errors.New("user already exists")
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
 	}
+
 	user := User{
 		uuid:         uuid.New().ID(),
 		login:        login,
-		passwordHash: password,
+		passwordHash: passwordHash,
 		email:        email,
 		name:         name,
 		score:        20,
+		rating:       ratingConfig.Initial,
 	}
 
-	users[login] = user
-	uuidUserIndex[user.uuid] = user.login
+	// the account exists only together with its starting score in the log
+	err = leaderboard.AddLogged(&user, &ScoreEvent{user: user.uuid, delta: user.score, at: timeNow(), source: ScoreSourceSignup})
+	if err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
 func Auth(login string, password string) (*User, error) {
-	user, ok := users[login]
-	if !ok {
-		return nil, errors.New("login")
+	user, err := userStore.GetByLogin(login)
+	if err != nil {
+		// spend as much time as with existing login,
+		// so response time doesn't tell which logins exist
+		VerifyPassword(dummyPasswordHash(), password)
+		return nil, ErrBadLogin
 	}
-	if user.passwordHash != password {
-		return nil, errors.New("password")
+	ok, needsRehash, err := VerifyPassword(user.passwordHash, password)
+	if err != nil || !ok {
+		return nil, ErrBadPassword
 	}
 
-	return &user, nil
+	if needsRehash {
+		// login still succeeds if upgrade fails, it will be retried next time.
+		// Only the hash is stored, user may have changed during hashing.
+		passwordHash, err := HashPassword(password)
+		if err == nil {
+			rehashed, err := UpdateUser(user.uuid, func(user *User) error {
+				user.passwordHash = passwordHash
+				return nil
+			})
+			if err == nil {
+				user = rehashed
+			}
+		}
+	}
+
+	return user, nil
 }
 
 func GetUserCount() (int, error) {
-	return len(users), nil
+	return userStore.Count()
 }
+
+// InitModels sets up in-memory storage
 func InitModels() {
-	users = make(map[string]User)
-	uuidUserIndex = make(map[uint32]string)
-	sessions = make(map[string]Session)
+	users := NewMemoryUserStore()
+	InitStores(users, NewMemorySessionStore(users), NewMemoryScoreEventStore())
+}
+
+// InitSQLiteModels sets up storage persisted in sqlite database at path
+func InitSQLiteModels(path string) error {
+	db, err := OpenSQLite(path)
+	if err != nil {
+		return err
+	}
+	users := NewSQLiteUserStore(db)
+	return InitStores(users, NewSQLiteSessionStore(db, users), NewSQLiteScoreEventStore(db))
+}
+
+func InitStores(users UserStore, sessions SessionStore, events ScoreEventStore) error {
+	ranked, err := NewRankedUserStore(users)
+	if err != nil {
+		return err
+	}
+	userSlice, err := users.All()
+	if err != nil {
+		return err
+	}
+	avatarRefs = NewAvatarRefs(userSlice)
+	leaderboard = ranked
+	userStore = ranked
+	sessionStore = sessions
+	scoreEventStore = events
+	// pending reports and queued players are users of the stores replaced
+	matches = matchRegistry{matches: make(map[string]*issuedMatch)}
+	matchQueue = NewMatchQueue(matchQueue.config, matchQueue.clock)
+	return ReplayPeriodLeaderboards(timeNow())
 }
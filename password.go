@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// PasswordParams describes how new password hashes are made.
+// Hashes made with other params are upgraded on successful login.
+type PasswordParams struct {
+	Algorithm string
+
+	BcryptCost int
+
+	ArgonTime    uint32
+	ArgonMemory  uint32 // KiB
+	ArgonThreads uint8
+	ArgonKeyLen  uint32
+	ArgonSaltLen uint32
+}
+
+var passwordParams = PasswordParams{
+	Algorithm:    HashArgon2id,
+	BcryptCost:   bcrypt.DefaultCost,
+	ArgonTime:    2,
+	ArgonMemory:  19 * 1024,
+	ArgonThreads: 1,
+	ArgonKeyLen:  32,
+	ArgonSaltLen: 16,
+}
+
+var errBadHash = errors.New("malformed password hash")
+
+// HashPassword hashes password with current passwordParams.
+// Result carries algorithm and params: bcrypt hashes are in the usual $2a$<cost>$ form,
+// argon2id ones in PHC form $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<hash>
+func HashPassword(password string) (string, error) {
+	params := passwordParams
+	switch params.Algorithm {
+	case HashBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), params.BcryptCost)
+		return string(hash), err
+	case HashArgon2id:
+		salt := make([]byte, params.ArgonSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		hash := argon2.IDKey([]byte(password), salt,
+			params.ArgonTime, params.ArgonMemory, params.ArgonThreads, params.ArgonKeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, params.ArgonMemory, params.ArgonTime, params.ArgonThreads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(hash)), nil
+	}
+	return "", errors.New("unknown password hash algorithm " + params.Algorithm)
+}
+
+// VerifyPassword checks password against encoded hash in constant time.
+// needsRehash is true if the hash was made with other than current passwordParams.
+// Hashes not starting with '$' are plaintext passwords of accounts
+// created before hashing was introduced, they always need rehash.
+func VerifyPassword(encoded string, password string) (ok bool, needsRehash bool, err error) {
+	params := passwordParams
+	switch {
+	case strings.HasPrefix(encoded, "$2"):
+		err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, false, err
+		}
+		return true, params.Algorithm != HashBcrypt || cost != params.BcryptCost, nil
+
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		var version int
+		var memory, time uint32
+		var threads uint8
+		parts := strings.Split(encoded, "$")
+		if len(parts) != 6 {
+			return false, false, errBadHash
+		}
+		if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return false, false, errBadHash
+		}
+		if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+			return false, false, errBadHash
+		}
+		salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+		if err != nil {
+			return false, false, errBadHash
+		}
+		hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+		if err != nil {
+			return false, false, errBadHash
+		}
+
+		otherHash := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(hash)))
+		if subtle.ConstantTimeCompare(hash, otherHash) != 1 {
+			return false, false, nil
+		}
+		needsRehash = params.Algorithm != HashArgon2id ||
+			memory != params.ArgonMemory || time != params.ArgonTime || threads != params.ArgonThreads ||
+			uint32(len(hash)) != params.ArgonKeyLen || uint32(len(salt)) != params.ArgonSaltLen
+		return true, needsRehash, nil
+
+	case strings.HasPrefix(encoded, "$"):
+		return false, false, errBadHash
+	}
+
+	return subtle.ConstantTimeCompare([]byte(encoded), []byte(password)) == 1, true, nil
+}
+
+var dummyHash struct {
+	sync.Mutex
+	params PasswordParams
+	hash   string
+}
+
+// dummyPasswordHash returns hash of nothing made with current passwordParams
+func dummyPasswordHash() string {
+	dummyHash.Lock()
+	defer dummyHash.Unlock()
+
+	if dummyHash.hash == "" || dummyHash.params != passwordParams {
+		dummyHash.params = passwordParams
+		dummyHash.hash, _ = HashPassword("")
+	}
+	return dummyHash.hash
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+)
+
+const skipListMaxLevel = 32
+
+type skipListLink struct {
+	next *skipListNode
+	span int // number of nodes this link jumps over, counting next
+}
+
+type skipListNode struct {
+	user  User
+	key   int // what user is ranked by, taken when user was set
+	links []skipListLink
+}
+
+// RankIndex orders users by descending key, ties are broken by login.
+// It is an indexable skip list, so both lookup of position and
+// lookup by position take O(log n).
+// RankIndex is not safe for concurrent use.
+type RankIndex struct {
+	head   *skipListNode
+	level  int
+	length int
+	nodes  map[uint32]*skipListNode
+	key    func(user *User) int
+}
+
+// NewRankIndex orders users by score
+func NewRankIndex() *RankIndex {
+	return NewRankIndexBy(scoreKey)
+}
+
+func NewRankIndexBy(key func(user *User) int) *RankIndex {
+	return &RankIndex{
+		head:  &skipListNode{links: make([]skipListLink, skipListMaxLevel)},
+		level: 1,
+		nodes: make(map[uint32]*skipListNode),
+		key:   key,
+	}
+}
+
+func scoreKey(user *User) int {
+	return user.score
+}
+
+func ratingKey(user *User) int {
+	return user.rating.Conservative()
+}
+
+func rankLess(a *User, b *User) bool {
+	return keyLess(a.score, a, b.score, b)
+}
+
+func keyLess(aKey int, a *User, bKey int, b *User) bool {
+	if aKey != bKey {
+		return aKey > bKey
+	}
+	if a.login != b.login {
+		return a.login < b.login
+	}
+	return a.uuid < b.uuid
+}
+
+func nodeLess(a *skipListNode, b *skipListNode) bool {
+	return keyLess(a.key, &a.user, b.key, &b.user)
+}
+
+func randomSkipListLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Intn(4) == 0 {
+		level++
+	}
+	return level
+}
+
+// Set adds user to the index or moves it if key has changed
+func (index *RankIndex) Set(user User) {
+	key := index.key(&user)
+	if node, ok := index.nodes[user.uuid]; ok {
+		if node.key == key && node.user.login == user.login {
+			node.user = user
+			return
+		}
+		index.Remove(user.uuid)
+	}
+	node := &skipListNode{user: user, key: key}
+
+	update := make([]*skipListNode, skipListMaxLevel)
+	rank := make([]int, skipListMaxLevel)
+	x := index.head
+	for i := index.level - 1; i >= 0; i-- {
+		if i != index.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.links[i].next != nil && nodeLess(x.links[i].next, node) {
+			rank[i] += x.links[i].span
+			x = x.links[i].next
+		}
+		update[i] = x
+	}
+
+	level := randomSkipListLevel()
+	if level > index.level {
+		for i := index.level; i < level; i++ {
+			update[i] = index.head
+			index.head.links[i].span = index.length
+		}
+		index.level = level
+	}
+
+	node.links = make([]skipListLink, level)
+	for i := 0; i < level; i++ {
+		node.links[i].next = update[i].links[i].next
+		update[i].links[i].next = node
+
+		node.links[i].span = update[i].links[i].span - (rank[0] - rank[i])
+		update[i].links[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < index.level; i++ {
+		update[i].links[i].span++
+	}
+
+	index.nodes[user.uuid] = node
+	index.length++
+}
+
+// Remove deletes user from the index, unknown uuids are ignored
+func (index *RankIndex) Remove(uuid uint32) {
+	node, ok := index.nodes[uuid]
+	if !ok {
+		return
+	}
+
+	x := index.head
+	for i := index.level - 1; i >= 0; i-- {
+		for x.links[i].next != nil && nodeLess(x.links[i].next, node) {
+			x = x.links[i].next
+		}
+		if x.links[i].next == node {
+			x.links[i].span += node.links[i].span - 1
+			x.links[i].next = node.links[i].next
+		} else {
+			x.links[i].span--
+		}
+	}
+	for index.level > 1 && index.head.links[index.level-1].next == nil {
+		index.level--
+	}
+
+	delete(index.nodes, uuid)
+	index.length--
+}
+
+// Rank returns 1-based position of user, 0 if user is not indexed
+func (index *RankIndex) Rank(uuid uint32) int {
+	node, ok := index.nodes[uuid]
+	if !ok {
+		return 0
+	}
+
+	rank := 0
+	x := index.head
+	for i := index.level - 1; i >= 0; i-- {
+		for x.links[i].next != nil && !nodeLess(node, x.links[i].next) {
+			rank += x.links[i].span
+			x = x.links[i].next
+		}
+		if x == node {
+			return rank
+		}
+	}
+	return 0
+}
+
+// Get returns indexed copy of user
+func (index *RankIndex) Get(uuid uint32) (User, bool) {
+	node, ok := index.nodes[uuid]
+	if !ok {
+		return User{}, false
+	}
+	return node.user, true
+}
+
+// Range returns up to count users starting from 0-based offset
+func (index *RankIndex) Range(offset int, count int) []User {
+	if offset < 0 || offset >= index.length || count <= 0 {
+		return []User{}
+	}
+
+	traversed := 0
+	x := index.head
+	for i := index.level - 1; i >= 0; i-- {
+		for x.links[i].next != nil && traversed+x.links[i].span <= offset+1 {
+			traversed += x.links[i].span
+			x = x.links[i].next
+		}
+	}
+
+	if count > index.length-offset {
+		count = index.length - offset
+	}
+	userSlice := make([]User, 0, count)
+	for ; x != nil && len(userSlice) < count; x = x.links[0].next {
+		userSlice = append(userSlice, x.user)
+	}
+	return userSlice
+}
+
+// Offset returns how many users rank before key, key itself does not have to be indexed.
+// Key score is what the index ranks by, not necessarily score.
+func (index *RankIndex) Offset(key *User) int {
+	offset := 0
+	x := index.head
+	for i := index.level - 1; i >= 0; i-- {
+		for x.links[i].next != nil && keyLess(x.links[i].next.key, &x.links[i].next.user, key.score, key) {
+			offset += x.links[i].span
+			x = x.links[i].next
+		}
+	}
+	return offset
+}
+
+// Seek returns up to count users right after key, or right before it if backward is set,
+// and 0-based offset of the first of them
+func (index *RankIndex) Seek(key User, count int, backward bool) ([]User, int) {
+	offset := index.Offset(&key)
+	if !backward {
+		if node, ok := index.nodes[key.uuid]; ok && node.key == key.score && node.user.login == key.login {
+			offset++
+		}
+		return index.Range(offset, count), offset
+	}
+	start := offset - count
+	if start < 0 {
+		start = 0
+	}
+	return index.Range(start, offset-start), start
+}
+
+func (index *RankIndex) Len() int {
+	return index.length
+}
+
+// RankedUserStore is a UserStore that keeps its users in a RankIndex
+// by score and in another one by conservative rating.
+// Writes to the underlying store and the indexes happen under one lock,
+// so the indexes never disagree with the store about a score.
+type RankedUserStore struct {
+	UserStore
+	mu      sync.RWMutex
+	index   *RankIndex
+	ratings *RankIndex
+}
+
+func NewRankedUserStore(store UserStore) (*RankedUserStore, error) {
+	userSlice, err := store.All()
+	if err != nil {
+		return nil, err
+	}
+
+	index := NewRankIndex()
+	ratings := NewRankIndexBy(ratingKey)
+	for _, user := range userSlice {
+		index.Set(user)
+		ratings.Set(user)
+	}
+	return &RankedUserStore{UserStore: store, index: index, ratings: ratings}, nil
+}
+
+func (store *RankedUserStore) Add(user *User) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	err := store.UserStore.Add(user)
+	if err != nil {
+		return err
+	}
+	store.index.Set(*user)
+	store.ratings.Set(*user)
+	return nil
+}
+
+func (store *RankedUserStore) Save(user *User) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	err := store.UserStore.Save(user)
+	if err != nil {
+		return err
+	}
+	store.index.Set(*user)
+	store.ratings.Set(*user)
+	return nil
+}
+
+// Update applies change to the latest saved version of user and saves it.
+// Other writes wait, so concurrent updates of one user are not lost.
+func (store *RankedUserStore) Update(uuid uint32, change func(user *User) error) (*User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, err := store.UserStore.Get(uuid)
+	if err != nil {
+		return nil, err
+	}
+	err = change(user)
+	if err != nil {
+		return nil, err
+	}
+	err = store.UserStore.Save(user)
+	if err != nil {
+		return nil, err
+	}
+	store.index.Set(*user)
+	store.ratings.Set(*user)
+	return user, nil
+}
+
+// AddLogged is Add that also appends event to the score log, both are stored or neither
+func (store *RankedUserStore) AddLogged(user *User, event *ScoreEvent) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	err := writeLogged(store.UserStore, user, nil, event)
+	if err != nil {
+		return err
+	}
+	store.index.Set(*user)
+	store.ratings.Set(*user)
+	return nil
+}
+
+// UpdateLogged is Update that also appends event to the score log,
+// the change and event are stored both or neither
+func (store *RankedUserStore) UpdateLogged(uuid uint32, change func(user *User) error, event *ScoreEvent) (*User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, err := store.UserStore.Get(uuid)
+	if err != nil {
+		return nil, err
+	}
+	previous := *user
+	err = change(user)
+	if err != nil {
+		return nil, err
+	}
+	err = writeLogged(store.UserStore, user, &previous, event)
+	if err != nil {
+		return nil, err
+	}
+	store.index.Set(*user)
+	store.ratings.Set(*user)
+	return user, nil
+}
+
+func (store *RankedUserStore) Delete(user *User) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	err := store.UserStore.Delete(user)
+	if err != nil {
+		return err
+	}
+	store.index.Remove(user.uuid)
+	store.ratings.Remove(user.uuid)
+	return nil
+}
+
+func (store *RankedUserStore) Count() (int, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.index.Len(), nil
+}
+
+// Page returns up to count users by descending score starting from 0-based offset
+func (store *RankedUserStore) Page(offset int, count int) []User {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.index.Range(offset, count)
+}
+
+// Rank returns 1-based position of user in the leaderboard, 0 if there is no such user
+func (store *RankedUserStore) Rank(uuid uint32) int {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.index.Rank(uuid)
+}
+
+// Seek returns up to count users right after key, or right before it if backward is set,
+// with 0-based offset of the first of them and total number of users.
+// Key is where the user stood, the user may have moved or gone since.
+func (store *RankedUserStore) Seek(key User, count int, backward bool) ([]User, int, int) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	userSlice, offset := store.index.Seek(key, count, backward)
+	return userSlice, offset, store.index.Len()
+}
+
+// Around returns user with up to neighbours users above and below, 0-based offset
+// of the first of them, total number of users and rank of user, which is 0 if there is no such user
+func (store *RankedUserStore) Around(uuid uint32, neighbours int) ([]User, int, int, int) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	rank := store.index.Rank(uuid)
+	if rank == 0 {
+		return []User{}, 0, store.index.Len(), 0
+	}
+	start := rank - 1 - neighbours
+	if start < 0 {
+		start = 0
+	}
+	return store.index.Range(start, rank+neighbours-start), start, store.index.Len(), rank
+}
+
+// Ratings are users ranked by conservative rating, cursor keys of its pages are ratings too
+func (store *RankedUserStore) Ratings() Standings {
+	return ratingStandings{store}
+}
+
+type ratingStandings struct {
+	store *RankedUserStore
+}
+
+func (standings ratingStandings) Count() (int, error) {
+	standings.store.mu.RLock()
+	defer standings.store.mu.RUnlock()
+
+	return standings.store.ratings.Len(), nil
+}
+
+func (standings ratingStandings) Page(offset int, count int) []User {
+	standings.store.mu.RLock()
+	defer standings.store.mu.RUnlock()
+
+	return standings.store.ratings.Range(offset, count)
+}
+
+func (standings ratingStandings) Seek(key User, count int, backward bool) ([]User, int, int) {
+	standings.store.mu.RLock()
+	defer standings.store.mu.RUnlock()
+
+	userSlice, offset := standings.store.ratings.Seek(key, count, backward)
+	return userSlice, offset, standings.store.ratings.Len()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// glickoScale converts Glicko ratings to Glicko-2 scale and back
+const glickoScale = 173.7178
+
+// Rating is Glicko-2 rating of a player. Deviation tells how uncertain
+// Rating is, Volatility how erratic the player performs.
+type Rating struct {
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+}
+
+type RatingConfig struct {
+	Initial Rating
+	// Tau constrains changes of volatility, sensible values are from 0.3 to 1.2
+	Tau float64
+	// ConservativeDeviations is how many deviations conservative rating is below rating
+	ConservativeDeviations float64
+	// ReportWindow is how long after a match is issued players may report its result
+	ReportWindow time.Duration
+}
+
+var ratingConfig = RatingConfig{
+	Initial:                Rating{1500, 350, 0.06},
+	Tau:                    0.5,
+	ConservativeDeviations: 2,
+	ReportWindow:           2 * time.Hour,
+}
+
+// MatchResult is an outcome of a match against Opponent,
+// Score is 1 for a win, 0.5 for a draw and 0 for a loss
+type MatchResult struct {
+	Opponent Rating
+	Score    float64
+}
+
+// Conservative is rating the player is almost surely above, leaderboard ranks by it
+func (rating Rating) Conservative() int {
+	return int(math.Round(rating.Rating - ratingConfig.ConservativeDeviations*rating.Deviation))
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// Update returns rating after a rating period with results.
+// Every match is a rating period of its own here.
+func (rating Rating) Update(results []MatchResult) Rating {
+	mu := (rating.Rating - 1500) / glickoScale
+	phi := rating.Deviation / glickoScale
+	sigma := rating.Volatility
+	if len(results) == 0 {
+		phi = math.Min(math.Sqrt(phi*phi+sigma*sigma), ratingConfig.Initial.Deviation/glickoScale)
+		return Rating{rating.Rating, phi * glickoScale, sigma}
+	}
+
+	var vInverse, improvement float64
+	for _, result := range results {
+		opponentMu := (result.Opponent.Rating - 1500) / glickoScale
+		g := glickoG(result.Opponent.Deviation / glickoScale)
+		expected := 1 / (1 + math.Exp(-g*(mu-opponentMu)))
+		vInverse += g * g * expected * (1 - expected)
+		improvement += g * (result.Score - expected)
+	}
+	v := 1 / vInverse
+	delta := v * improvement
+
+	// new volatility is the root of f, found with the Illinois algorithm
+	tau := ratingConfig.Tau
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		return ex*(delta*delta-phi*phi-v-ex)/(2*(phi*phi+v+ex)*(phi*phi+v+ex)) - (x-a)/(tau*tau)
+	}
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > 1e-6 {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	sigma = math.Exp(A / 2)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	mu += phi * phi * improvement
+	return Rating{mu*glickoScale + 1500, phi * glickoScale, sigma}
+}
+
+var (
+	ErrMatchReported = &GameError{"already_reported", "match", "match result already reported"}
+	ErrMatchPlayers  = &GameError{"wrong_players", "opponent", "match is between other players"}
+	ErrMatchDisputed = &GameError{"disputed_result", "result", "players reported different results"}
+	ErrUnknownMatch  = &GameError{"unknown_match", "match", "no such match"}
+)
+
+var matchScores = map[string]float64{"win": 1, "draw": 0.5, "loss": 0}
+
+// issuedMatch is a match the server set up between two players, only these
+// are rated. reporter is set by the first report, rated once the other one agrees.
+type issuedMatch struct {
+	players  [2]uint32
+	issued   time.Time
+	reported bool
+	reporter uint32
+	score    float64
+	rated    bool
+}
+
+type matchRegistry struct {
+	mu        sync.Mutex
+	matches   map[string]*issuedMatch
+	lastSweep time.Time
+}
+
+var matches = matchRegistry{matches: make(map[string]*issuedMatch)}
+
+// drop matches nobody can report anymore
+func (registry *matchRegistry) sweep(now time.Time) {
+	if now.Sub(registry.lastSweep) < ratingConfig.ReportWindow {
+		return
+	}
+	for id, match := range registry.matches {
+		if now.Sub(match.issued) > ratingConfig.ReportWindow {
+			delete(registry.matches, id)
+		}
+	}
+	registry.lastSweep = now
+}
+
+// IssueMatch lets players report the result of match between them,
+// issuing a match again changes nothing
+func IssueMatch(match string, players [2]uint32, at time.Time) {
+	matches.mu.Lock()
+	defer matches.mu.Unlock()
+
+	matches.sweep(at)
+	if _, ok := matches.matches[match]; !ok {
+		matches.matches[match] = &issuedMatch{players: players, issued: at}
+	}
+}
+
+// ReportMatch takes result of match between user and opponent as user tells it.
+// Both players report, ratings change once the second report agrees with the first.
+// It returns user with the new rating, or nil while the opponent has not reported yet.
+func ReportMatch(user *User, match string, opponentLogin string, result string) (*User, error) {
+	score, ok := matchScores[result]
+	if !ok {
+		return nil, &ValidationError{[]FieldError{{"result", "invalid", "result must be win, draw or loss"}}}
+	}
+	if match == "" {
+		return nil, missingField("match")
+	}
+	opponent, err := GetUserByLogin(opponentLogin)
+	if err != nil || opponent.uuid == user.uuid {
+		return nil, &ValidationError{[]FieldError{{"opponent", "invalid", "unknown opponent"}}}
+	}
+
+	now := timeNow()
+	matches.mu.Lock()
+	matches.sweep(now)
+	issued, ok := matches.matches[match]
+	switch {
+	case !ok || now.Sub(issued.issued) > ratingConfig.ReportWindow:
+		matches.mu.Unlock()
+		return nil, ErrUnknownMatch
+	case issued.players != [2]uint32{user.uuid, opponent.uuid} && issued.players != [2]uint32{opponent.uuid, user.uuid}:
+		matches.mu.Unlock()
+		return nil, ErrMatchPlayers
+	case issued.rated || (issued.reported && issued.reporter == user.uuid):
+		matches.mu.Unlock()
+		return nil, ErrMatchReported
+	case !issued.reported:
+		issued.reported, issued.reporter, issued.score = true, user.uuid, score
+		matches.mu.Unlock()
+		return nil, nil
+	case issued.score+score != 1:
+		// neither result can be trusted, the match is not rated
+		issued.rated = true
+		matches.mu.Unlock()
+		return nil, ErrMatchDisputed
+	}
+	issued.rated = true
+	matches.mu.Unlock()
+
+	return RateMatch(user, opponent, score)
+}
+
+// RateMatch updates ratings of both players after a match,
+// score is 1 if user won, 0.5 for a draw and 0 if opponent won
+func RateMatch(user *User, opponent *User, score float64) (*User, error) {
+	// both are rated against ratings they had before the match
+	userRating, opponentRating := user.rating, opponent.rating
+	if latest, err := GetUser(user.uuid); err == nil {
+		userRating = latest.rating
+	}
+	if latest, err := GetUser(opponent.uuid); err == nil {
+		opponentRating = latest.rating
+	}
+
+	_, err := UpdateUser(opponent.uuid, func(opponent *User) error {
+		opponent.rating = opponent.rating.Update([]MatchResult{{userRating, 1 - score}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return UpdateUser(user.uuid, func(user *User) error {
+		user.rating = user.rating.Update([]MatchResult{{opponentRating, score}})
+		return nil
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+const (
+	historyPageSize = 20
+	maxHistoryCount = 100
+)
+
+// Sources of score events
+const (
+	ScoreSourceSignup = "signup" // score every user starts with
+	ScoreSourceGame   = "game"   // result of a finished game, match is the game token
+	ScoreSourceImport = "import" // score users had before the log was kept
+)
+
+// ScoreEvent is a change of user score. Events are never changed
+// or removed, so the sum of user events is user score.
+type ScoreEvent struct {
+	id     int64
+	user   uint32
+	delta  int
+	match  string
+	at     time.Time
+	source string
+}
+
+// AddScore changes user score by delta and logs the change.
+// Both happen under the leaderboard lock, so the log has events
+// in the same order the score changed, and both are stored or neither.
+func AddScore(uuid uint32, delta int, match string, source string) (*User, error) {
+	event := &ScoreEvent{user: uuid, delta: delta, match: match, at: timeNow(), source: source}
+	user, err := leaderboard.UpdateLogged(uuid, func(user *User) error {
+		user.score += delta
+		return nil
+	}, event)
+	if err != nil {
+		return nil, err
+	}
+	if source == ScoreSourceGame {
+		RecordScore(user, delta, event.at)
+	}
+	return user, nil
+}
+
+// ReplayScore sums all logged score changes of user
+func ReplayScore(uuid uint32) (int, error) {
+	score := 0
+	var before int64
+	for {
+		eventSlice, err := scoreEventStore.ListByUser(uuid, before, maxHistoryCount)
+		if err != nil {
+			return 0, err
+		}
+		for _, event := range eventSlice {
+			score += event.delta
+		}
+		if len(eventSlice) < maxHistoryCount {
+			return score, nil
+		}
+		before = eventSlice[len(eventSlice)-1].id
+	}
+}
+
+// ScoreHistoryPage is a part of user score log, newest events first.
+// Next is cursor of the following page, empty if this page is the last.
+type ScoreHistoryPage struct {
+	Events []ScoreEvent
+	Next   string
+}
+
+func historyCursor(event ScoreEvent) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(event.id, 10)))
+}
+
+func parseHistoryCursor(value string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		var id int64
+		id, err = strconv.ParseInt(string(raw), 10, 64)
+		if err == nil && id > 0 {
+			return id, nil
+		}
+	}
+	return 0, &ValidationError{[]FieldError{{"cursor", "invalid", "invalid cursor"}}}
+}
+
+// GetScoreHistory returns page of user score log starting after cursor, or the newest one if cursor is empty.
+// Count defaults to historyPageSize and may not exceed maxHistoryCount.
+func GetScoreHistory(user *User, count int, cursor string) (*ScoreHistoryPage, error) {
+	if count == 0 {
+		count = historyPageSize
+	}
+	if count < 1 || count > maxHistoryCount {
+		return nil, &ValidationError{[]FieldError{{"count", "out_of_range",
+			"count must be from 1 to " + strconv.Itoa(maxHistoryCount)}}}
+	}
+	var before int64
+	if cursor != "" {
+		var err error
+		before, err = parseHistoryCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// one more event tells whether there is a next page
+	eventSlice, err := scoreEventStore.ListByUser(user.uuid, before, count+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &ScoreHistoryPage{Events: eventSlice}
+	if len(eventSlice) > count {
+		page.Events = eventSlice[:count]
+		page.Next = historyCursor(page.Events[count-1])
+	}
+	return page, nil
+}
@@ -1,20 +1,30 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
+	"os"
 	"path"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
 
+// allowedOrigins may make requests with user cookies from other hosts
+var allowedOrigins = []string{"http://kpacubo.xyz", "http://api.kpacubo.xyz"}
+
 func NewRouter() http.Handler {
-	allowOrigins := handlers.AllowedOrigins([]string{"http://kpacubo.xyz", "http://api.kpacubo.xyz"})
-	allowHeaders := handlers.AllowedHeaders([]string{"X-Requested-With"})
-	allowMethods := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "OPTIONS"})
+	allowOrigins := handlers.AllowedOrigins(allowedOrigins)
+	allowHeaders := handlers.AllowedHeaders([]string{"X-Requested-With", csrfHeader})
+	allowMethods := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"})
+	// token renewed at login comes in this header, other origins can't read it otherwise
+	exposeHeaders := handlers.ExposedHeaders([]string{csrfHeader})
 
 	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(HandleNotFound)
+	r.MethodNotAllowedHandler = http.HandlerFunc(HandleMethodNotAllowed)
 
 	r.HandleFunc("/api/auth", SessionMiddleware(HandleLogin, false)).Methods("POST")                        // check, но изменить ошибки
 	r.HandleFunc("/api/register", SessionMiddleware(HandleRegister, false)).Methods("POST")                 // принимает неполные запросыFFF
@@ -23,11 +33,33 @@ func NewRouter() http.Handler {
 	r.HandleFunc("/api/profile", SessionMiddleware(HandleGetUserData, true)).Methods("GET")                 // хз вроде норм
 	r.HandleFunc("/api/leaderboard/{page:[0-9]+}", SessionMiddleware(HandleGetUsers, false)).Methods("GET") // -
 
+	r.HandleFunc("/api/csrf", SessionMiddleware(HandleCSRFToken, false)).Methods("GET")
+	r.HandleFunc("/api/logout", SessionMiddleware(HandleLogout, true)).Methods("POST")
+	r.HandleFunc("/api/sessions", SessionMiddleware(HandleGetSessions, true)).Methods("GET")
+	r.HandleFunc("/api/sessions/{id}", SessionMiddleware(HandleDeleteSession, true)).Methods("DELETE")
+	r.HandleFunc("/api/game/start", SessionMiddleware(HandleGameStart, true)).Methods("POST")
+	r.HandleFunc("/api/score", SessionMiddleware(HandleScore, true)).Methods("POST")
+	r.HandleFunc("/api/profile/history", SessionMiddleware(HandleGetHistory, true)).Methods("GET")
+	r.HandleFunc("/api/match/result", SessionMiddleware(HandleMatchResult, true)).Methods("POST")
+	r.HandleFunc("/api/ws", SessionMiddleware(HandleWebSocket, true)).Methods("GET")
+	r.HandleFunc("/api/matchmaking", SessionMiddleware(HandleGetMatchmaking, true)).Methods("GET")
+	r.HandleFunc("/api/matchmaking", SessionMiddleware(HandleEnqueue, true)).Methods("POST")
+	r.HandleFunc("/api/matchmaking", SessionMiddleware(HandleDequeue, true)).Methods("DELETE")
+	r.HandleFunc("/api/leaderboard", SessionMiddleware(HandleGetUsers, false)).Methods("GET")
+	r.HandleFunc("/api/leaderboard/me", SessionMiddleware(HandleGetLeaderboardAroundMe, true)).Methods("GET")
+	r.HandleFunc("/api/leaderboard/{period:all|daily|weekly|monthly|rating}", SessionMiddleware(HandleGetUsers, false)).Methods("GET")
+	r.HandleFunc("/api/leaderboard/{period:all|daily|weekly|monthly|rating}/{page:[0-9]+}", SessionMiddleware(HandleGetUsers, false)).Methods("GET")
+
 	staticServer := http.FileServer(http.Dir(
 		path.Join("..", "2019_1_DeathPacito_front", "public")))
-	mediaServer := http.FileServer(http.Dir("media/"))
 
-	r.PathPrefix("/media").Handler(http.StripPrefix("/media/", mediaServer))
+	r.HandleFunc(defaultAvatarPrefix+"{uuid:[0-9]+}", HandleDefaultAvatar).Methods("GET", "HEAD")
+	r.HandleFunc(defaultAvatarPrefix+"{uuid:[0-9]+}.svg", HandleDefaultAvatar).Methods("GET", "HEAD")
+	// blobs of other stores are downloaded from the store itself
+	if localStore, ok := mediaStore.(*LocalBlobStore); ok {
+		mediaServer := http.FileServer(http.Dir(localStore.Dir))
+		r.PathPrefix(localStore.URLPrefix).Handler(http.StripPrefix(localStore.URLPrefix, mediaServer))
+	}
 	r.PathPrefix("/public").Handler(http.StripPrefix("/public/", staticServer))
 
 	r.HandleFunc("/", SessionMiddleware(func(w http.ResponseWriter, r *http.Request, s *Session) {
@@ -35,10 +67,56 @@ func NewRouter() http.Handler {
 			"..", "2019_1_DeathPacito_front",
 			"public", "index.html"))
 	}, false))
-	return handlers.CORS(allowOrigins, allowHeaders, allowMethods)(r)
+	return handlers.CORS(allowOrigins, allowHeaders, allowMethods, exposeHeaders)(r)
 }
 func main() {
-	InitModels()
+	dbPath := flag.String("db", "", "path to sqlite database, data is kept in memory if empty")
+	flag.StringVar(&passwordParams.Algorithm, "password-hash", passwordParams.Algorithm, "password hash algorithm: argon2id or bcrypt")
+	flag.IntVar(&passwordParams.BcryptCost, "bcrypt-cost", passwordParams.BcryptCost, "bcrypt cost")
+	flag.BoolVar(&cookieConfig.Secure, "secure-cookies", false, "send session cookie over https only")
+	flag.StringVar(&cookieConfig.Domain, "cookie-domain", "", "session cookie domain")
+	flag.BoolVar(&genericAuthErrors, "generic-auth-errors", false, "don't tell whether login or password was wrong")
+	s3Config := S3Config{
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+	}
+	flag.StringVar(&s3Config.Endpoint, "s3-endpoint", "", "S3 compatible storage for media, local media directory is used if empty")
+	flag.StringVar(&s3Config.Region, "s3-region", "us-east-1", "S3 region")
+	flag.StringVar(&s3Config.Bucket, "s3-bucket", "media", "S3 bucket, it has to allow anonymous reads")
+	flag.StringVar(&s3Config.PublicURL, "s3-public-url", "", "URL media is downloaded from, s3-endpoint/s3-bucket if empty")
+	flag.DurationVar(&periodConfig.DayStart, "leaderboard-day-start", 0, "daily leaderboards roll over this long after UTC midnight")
+	weekStart := flag.Int("leaderboard-week-start", int(periodConfig.WeekStart), "weekday weekly leaderboards roll over on, 0 is Sunday")
+	flag.IntVar(&periodConfig.Archived, "leaderboard-archive", periodConfig.Archived, "how many past periods of every leaderboard to keep")
+	collectAvatars := flag.Bool("gc-avatars", false, "remove avatars nobody uses and exit")
+	flag.Parse()
+	periodConfig.WeekStart = time.Weekday(*weekStart)
+
+	// a fresh in-memory store has no users yet, every avatar would look unused
+	if *collectAvatars && *dbPath == "" {
+		log.Fatal("-gc-avatars needs -db")
+	}
+
+	if s3Config.Endpoint != "" {
+		mediaStore = NewS3BlobStore(s3Config)
+	}
+
+	if *dbPath == "" {
+		InitModels()
+	} else if err := InitSQLiteModels(*dbPath); err != nil {
+		log.Fatal(err)
+	}
+	if *collectAvatars {
+		count, err := CollectAvatarGarbage(timeNow())
+		log.Println("removed", count, "avatar blobs")
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	StartSessionJanitor(sessionConfig.ReapInterval)
+	// users lost with an in-memory store are gone for good, so are their avatars
+	StartAvatarGC(avatarConfig.GCInterval)
+	StartMatchmaker(matchmakingConfig.Interval)
 
 	log.Fatal(http.ListenAndServe(":8080", NewRouter()))
 }
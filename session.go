@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"time"
+)
+
+// SessionConfig sets how long sessions live.
+// A session expires MaxAge after it was created or IdleTimeout after
+// the last request made with it, whichever comes first.
+type SessionConfig struct {
+	MaxAge       time.Duration
+	IdleTimeout  time.Duration
+	ReapInterval time.Duration
+}
+
+var sessionConfig = SessionConfig{
+	MaxAge:       30 * 24 * time.Hour,
+	IdleTimeout:  7 * 24 * time.Hour,
+	ReapInterval: 10 * time.Minute,
+}
+
+func (session *Session) Expires() time.Time {
+	return session.created.Add(sessionConfig.MaxAge)
+}
+
+func (session *Session) Expired(now time.Time) bool {
+	return !now.Before(session.Expires()) ||
+		!now.Before(session.lastSeen.Add(sessionConfig.IdleTimeout))
+}
+
+// Renew moves session to a fresh sid and CSRF token keeping the rest of its state,
+// old sid and token stop working. Renewed session lives as if it was just created.
+func (session *Session) Renew() error {
+	if session.stored {
+		err := session.Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	fresh := NewSession()
+	session.sid = fresh.sid
+	session.created = fresh.created
+	session.lastSeen = fresh.lastSeen
+	session.deleted = false
+	session.csrfToken = ""
+	_, _, err := session.CSRFToken()
+	if err != nil {
+		return err
+	}
+	return session.Save()
+}
+
+// CSRFToken returns synchronizer token of the session, making one if needed.
+// Session has to be saved if token is new.
+func (session *Session) CSRFToken() (token string, isNew bool, err error) {
+	if session.csrfToken != "" {
+		return session.csrfToken, false, nil
+	}
+
+	session.csrfToken, err = newCSRFToken()
+	if err != nil {
+		return "", false, err
+	}
+	return session.csrfToken, true, nil
+}
+
+func newCSRFToken() (string, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func (session *Session) CheckCSRFToken(token string) bool {
+	return session.csrfToken != "" &&
+		subtle.ConstantTimeCompare([]byte(session.csrfToken), []byte(token)) == 1
+}
+
+// DeleteExpiredSessions removes sessions expired by now, returns how many were removed
+func DeleteExpiredSessions(now time.Time) (int, error) {
+	return sessionStore.DeleteExpired(now.Add(-sessionConfig.MaxAge), now.Add(-sessionConfig.IdleTimeout))
+}
+
+// StartSessionJanitor removes expired sessions every interval until stop is called
+func StartSessionJanitor(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				_, err := DeleteExpiredSessions(timeNow())
+				if err != nil {
+					log.Println("session janitor:", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// UserStore keeps registered users.
+// Add must fail if the login is already taken, Save inserts or replaces.
+// Implementations must be safe for concurrent use.
+type UserStore interface {
+	Add(user *User) error
+	Save(user *User) error
+	Delete(user *User) error
+	Get(uuid uint32) (*User, error)
+	GetByLogin(login string) (*User, error)
+	All() ([]User, error)
+	Count() (int, error)
+}
+
+// SessionStore keeps sessions by sid.
+// ListByUser leaves out expired sessions that are not removed yet.
+// Touch updates last seen time and user agent of a session that is still
+// stored, a deleted session is never written back.
+// DeleteExpired removes sessions created before createdBefore or
+// last seen before seenBefore.
+// Implementations must be safe for concurrent use.
+type SessionStore interface {
+	Save(session *Session) error
+	Touch(session *Session) error
+	Delete(session *Session) error
+	Get(sid string) (*Session, error)
+	ListByUser(uuid uint32) ([]Session, error)
+	DeleteExpired(createdBefore time.Time, seenBefore time.Time) (int, error)
+}
+
+// ScoreEventStore keeps the append-only log of score changes.
+// Append assigns id to event, ids of later events are greater.
+// ListByUser returns up to count events of user with id below before
+// (any id if before is 0), newest first. Since returns events
+// that happened at t or later, oldest first.
+// Implementations must be safe for concurrent use.
+type ScoreEventStore interface {
+	Append(event *ScoreEvent) error
+	ListByUser(uuid uint32, before int64, count int) ([]ScoreEvent, error)
+	Since(t time.Time) ([]ScoreEvent, error)
+}
+
+// ScoreLogger is a UserStore that writes user together with
+// a score event in one transaction, so both are stored or neither
+type ScoreLogger interface {
+	AddLogged(user *User, event *ScoreEvent) error
+	SaveLogged(user *User, event *ScoreEvent) error
+}
+
+// writeLogged adds user, or saves it over previous if that is not nil, and appends
+// event to the score log. Both are stored or neither: in one transaction if users
+// is a ScoreLogger, otherwise the user write is undone if event can't be appended.
+func writeLogged(users UserStore, user *User, previous *User, event *ScoreEvent) error {
+	if logger, ok := users.(ScoreLogger); ok {
+		if previous == nil {
+			return logger.AddLogged(user, event)
+		}
+		return logger.SaveLogged(user, event)
+	}
+
+	var err error
+	if previous == nil {
+		err = users.Add(user)
+	} else {
+		err = users.Save(user)
+	}
+	if err != nil {
+		return err
+	}
+	err = scoreEventStore.Append(event)
+	if err != nil {
+		if previous == nil {
+			users.Delete(user)
+		} else {
+			users.Save(previous)
+		}
+	}
+	return err
+}
+
+// MemoryUserStore is a UserStore that lives only while the process is running
+type MemoryUserStore struct {
+	mu            sync.RWMutex
+	users         map[string]User
+	uuidUserIndex map[uint32]string
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{
+		users:         make(map[string]User),
+		uuidUserIndex: make(map[uint32]string),
+	}
+}
+
+func (store *MemoryUserStore) Add(user *User) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[user.login]; ok {
+		return ErrUserExists
+	}
+	store.save(user)
+	return nil
+}
+
+func (store *MemoryUserStore) Save(user *User) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.save(user)
+	return nil
+}
+
+func (store *MemoryUserStore) save(user *User) {
+	store.users[user.login] = *user
+	store.uuidUserIndex[user.uuid] = user.login
+}
+
+func (store *MemoryUserStore) Delete(user *User) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.uuidUserIndex, user.uuid)
+	delete(store.users, user.login)
+	return nil
+}
+
+func (store *MemoryUserStore) Get(uuid uint32) (*User, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	login, exists := store.uuidUserIndex[uuid]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	user, ok := store.users[login]
+	if !ok {
+		return nil, errors.New("uuid-login match error")
+	}
+
+	return &user, nil
+}
+
+func (store *MemoryUserStore) GetByLogin(login string) (*User, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	user, exists := store.users[login]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return &user, nil
+}
+
+func (store *MemoryUserStore) All() ([]User, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	userSlice := make([]User, 0, len(store.users))
+	for _, user := range store.users {
+		userSlice = append(userSlice, user)
+	}
+	return userSlice, nil
+}
+
+func (store *MemoryUserStore) Count() (int, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return len(store.users), nil
+}
+
+// MemoryScoreEventStore is a ScoreEventStore that lives only while the process is running
+type MemoryScoreEventStore struct {
+	mu     sync.RWMutex
+	events []ScoreEvent
+	byUser map[uint32][]int // positions of user events in events
+}
+
+func NewMemoryScoreEventStore() *MemoryScoreEventStore {
+	return &MemoryScoreEventStore{byUser: make(map[uint32][]int)}
+}
+
+func (store *MemoryScoreEventStore) Append(event *ScoreEvent) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	event.id = int64(len(store.events) + 1)
+	store.byUser[event.user] = append(store.byUser[event.user], len(store.events))
+	store.events = append(store.events, *event)
+	return nil
+}
+
+func (store *MemoryScoreEventStore) ListByUser(uuid uint32, before int64, count int) ([]ScoreEvent, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	eventSlice := make([]ScoreEvent, 0)
+	positions := store.byUser[uuid]
+	for i := len(positions) - 1; i >= 0 && len(eventSlice) < count; i-- {
+		event := store.events[positions[i]]
+		if before == 0 || event.id < before {
+			eventSlice = append(eventSlice, event)
+		}
+	}
+	return eventSlice, nil
+}
+
+func (store *MemoryScoreEventStore) Since(t time.Time) ([]ScoreEvent, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	eventSlice := make([]ScoreEvent, 0)
+	for _, event := range store.events {
+		if !event.at.Before(t) {
+			eventSlice = append(eventSlice, event)
+		}
+	}
+	return eventSlice, nil
+}
+
+const sessionShardCount = 32
+
+type sessionShard struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+// MemorySessionStore is a SessionStore that lives only while the process is running.
+// Sessions are spread over shards so requests with different sids don't contend.
+// Session users are resolved through users store on load, so every request
+// gets its own copy of the user.
+type MemorySessionStore struct {
+	shards [sessionShardCount]sessionShard
+	users  UserStore
+}
+
+func NewMemorySessionStore(users UserStore) *MemorySessionStore {
+	store := &MemorySessionStore{users: users}
+	for i := range store.shards {
+		store.shards[i].sessions = make(map[string]Session)
+	}
+	return store
+}
+
+func (store *MemorySessionStore) shard(sid string) *sessionShard {
+	hash := fnv.New32a()
+	hash.Write([]byte(sid))
+	return &store.shards[hash.Sum32()%sessionShardCount]
+}
+
+func (store *MemorySessionStore) Save(session *Session) error {
+	stored := *session
+	if session.user != nil {
+		user := *session.user
+		stored.user = &user
+	}
+
+	shard := store.shard(session.sid)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.sessions[session.sid] = stored
+	return nil
+}
+
+func (store *MemorySessionStore) Touch(session *Session) error {
+	shard := store.shard(session.sid)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	stored, exists := shard.sessions[session.sid]
+	if !exists {
+		return nil
+	}
+	stored.lastSeen = session.lastSeen
+	stored.userAgent = session.userAgent
+	shard.sessions[session.sid] = stored
+	return nil
+}
+
+func (store *MemorySessionStore) Delete(session *Session) error {
+	shard := store.shard(session.sid)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	delete(shard.sessions, session.sid)
+	return nil
+}
+
+func (store *MemorySessionStore) Get(sid string) (*Session, error) {
+	shard := store.shard(sid)
+	shard.mu.RLock()
+	session, exists := shard.sessions[sid]
+	shard.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	if session.user != nil {
+		user, err := store.users.Get(session.user.uuid)
+		if err != nil {
+			return nil, err
+		}
+		session.user = user
+	}
+	return &session, nil
+}
+
+func (store *MemorySessionStore) ListByUser(uuid uint32) ([]Session, error) {
+	now := timeNow()
+	sessionSlice := make([]Session, 0)
+	for i := range store.shards {
+		shard := &store.shards[i]
+		shard.mu.RLock()
+		for _, session := range shard.sessions {
+			if session.user != nil && session.user.uuid == uuid && !session.Expired(now) {
+				sessionSlice = append(sessionSlice, session)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	return sessionSlice, nil
+}
+
+func (store *MemorySessionStore) DeleteExpired(createdBefore time.Time, seenBefore time.Time) (int, error) {
+	count := 0
+	for i := range store.shards {
+		shard := &store.shards[i]
+		shard.mu.Lock()
+		for sid, session := range shard.sessions {
+			if session.created.Before(createdBefore) || session.lastSeen.Before(seenBefore) {
+				delete(shard.sessions, sid)
+				count++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return count, nil
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS users (
+	uuid          INTEGER PRIMARY KEY,
+	login         TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	email         TEXT NOT NULL,
+	name          TEXT NOT NULL,
+	avatar        TEXT NOT NULL DEFAULT '',
+	score         INTEGER NOT NULL DEFAULT 0,
+	rating        REAL NOT NULL DEFAULT 1500,
+	deviation     REAL NOT NULL DEFAULT 350,
+	volatility    REAL NOT NULL DEFAULT 0.06
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	sid        TEXT PRIMARY KEY,
+	id         TEXT NOT NULL UNIQUE,
+	user_uuid  INTEGER REFERENCES users(uuid) ON DELETE SET NULL,
+	user_agent TEXT NOT NULL DEFAULT '',
+	csrf_token TEXT NOT NULL DEFAULT '',
+	created    INTEGER NOT NULL,
+	last_seen  INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_uuid ON sessions(user_uuid);
+CREATE UNIQUE INDEX IF NOT EXISTS sessions_id ON sessions(id);
+
+CREATE TABLE IF NOT EXISTS score_events (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uuid INTEGER NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+	delta     INTEGER NOT NULL,
+	match     TEXT NOT NULL DEFAULT '',
+	at        INTEGER NOT NULL,
+	source    TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS score_events_user_uuid ON score_events(user_uuid, id);
+CREATE INDEX IF NOT EXISTS score_events_at ON score_events(at);
+`
+
+// sqliteColumn is a column added to a table after the table was first created.
+// Databases made before get it with ALTER TABLE, then fill sets it up
+// in rows they have.
+type sqliteColumn struct {
+	table      string
+	name       string
+	definition string
+	fill       string
+}
+
+// sqliteAddedColumns are added before the schema, whose indexes may need them
+var sqliteAddedColumns = []sqliteColumn{
+	{"sessions", "id", "TEXT NOT NULL DEFAULT ''", "UPDATE sessions SET id = lower(hex(randomblob(16)))"},
+	{"sessions", "user_agent", "TEXT NOT NULL DEFAULT ''", ""},
+	{"sessions", "csrf_token", "TEXT NOT NULL DEFAULT ''", ""},
+	// sessions made before are as old as the migration, not expired at once
+	{"sessions", "created", "INTEGER NOT NULL DEFAULT 0",
+		"UPDATE sessions SET created = CAST(strftime('%s', 'now') AS INTEGER) * 1000000000"},
+	{"sessions", "last_seen", "INTEGER NOT NULL DEFAULT 0", "UPDATE sessions SET last_seen = created"},
+	{"users", "rating", "REAL NOT NULL DEFAULT 1500", ""},
+	{"users", "deviation", "REAL NOT NULL DEFAULT 350", ""},
+	{"users", "volatility", "REAL NOT NULL DEFAULT 0.06", ""},
+}
+
+// users older than the score log get their whole score as one imported event
+const sqliteImportScores = `
+INSERT INTO score_events (user_uuid, delta, at, source)
+SELECT uuid, score, ?, '` + ScoreSourceImport + `' FROM users
+WHERE score != 0 AND uuid NOT IN (SELECT user_uuid FROM score_events)
+`
+
+const userColumns = "uuid, login, password_hash, email, name, avatar, score, rating, deviation, volatility"
+const sessionColumns = "sid, id, user_uuid, user_agent, csrf_token, created, last_seen"
+const scoreEventColumns = "id, user_uuid, delta, match, at, source"
+
+// OpenSQLite opens (and creates if needed) sqlite database at path
+func OpenSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=1")
+	if err != nil {
+		return nil, err
+	}
+	// sqlite allows only one writer at a time anyway
+	db.SetMaxOpenConns(1)
+
+	err = sqliteAddColumns(db)
+	if err == nil {
+		_, err = db.Exec(sqliteSchema)
+	}
+	if err == nil {
+		_, err = db.Exec(sqliteImportScores, timeNow().UnixNano())
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqliteAddColumns adds columns missing from tables that exist already
+func sqliteAddColumns(db *sql.DB) error {
+	for _, column := range sqliteAddedColumns {
+		columns, err := sqliteColumns(db, column.table)
+		if err != nil {
+			return err
+		}
+		// the table is created with all columns by the schema
+		if len(columns) == 0 || columns[column.name] {
+			continue
+		}
+		_, err = db.Exec("ALTER TABLE " + column.table + " ADD COLUMN " + column.name + " " + column.definition)
+		if err == nil && column.fill != "" {
+			_, err = db.Exec(column.fill)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sqliteColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, primaryKey int
+		var name, columnType string
+		var defaultValue sql.NullString
+		err = rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &primaryKey)
+		if err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// SQLiteUserStore is a UserStore persisted in sqlite database
+type SQLiteUserStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserStore(db *sql.DB) *SQLiteUserStore {
+	return &SQLiteUserStore{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
+	user := User{}
+	err := row.Scan(&user.uuid, &user.login, &user.passwordHash,
+		&user.email, &user.name, &user.avatar, &user.score,
+		&user.rating.Rating, &user.rating.Deviation, &user.rating.Volatility)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// sqliteExecer is either the database or a transaction in it
+type sqliteExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func addUser(db sqliteExecer, user *User) error {
+	_, err := db.Exec("INSERT INTO users ("+userColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		user.uuid, user.login, user.passwordHash, user.email, user.name, user.avatar, user.score,
+		user.rating.Rating, user.rating.Deviation, user.rating.Volatility)
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.Code == sqlite3.ErrConstraint {
+		return ErrUserExists
+	}
+	return err
+}
+
+func saveUser(db sqliteExecer, user *User) error {
+	// not INSERT OR REPLACE: replacing the row would detach its sessions
+	_, err := db.Exec("INSERT INTO users ("+userColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) "+
+		"ON CONFLICT(uuid) DO UPDATE SET login = excluded.login, password_hash = excluded.password_hash, "+
+		"email = excluded.email, name = excluded.name, avatar = excluded.avatar, score = excluded.score, "+
+		"rating = excluded.rating, deviation = excluded.deviation, volatility = excluded.volatility",
+		user.uuid, user.login, user.passwordHash, user.email, user.name, user.avatar, user.score,
+		user.rating.Rating, user.rating.Deviation, user.rating.Volatility)
+	return err
+}
+
+func (store *SQLiteUserStore) Add(user *User) error {
+	return addUser(store.db, user)
+}
+
+func (store *SQLiteUserStore) Save(user *User) error {
+	return saveUser(store.db, user)
+}
+
+// AddLogged adds user and appends event in one transaction.
+// Events go to the score_events table SQLiteScoreEventStore of the same database reads.
+func (store *SQLiteUserStore) AddLogged(user *User, event *ScoreEvent) error {
+	return store.writeLogged(addUser, user, event)
+}
+
+// SaveLogged saves user and appends event in one transaction
+func (store *SQLiteUserStore) SaveLogged(user *User, event *ScoreEvent) error {
+	return store.writeLogged(saveUser, user, event)
+}
+
+func (store *SQLiteUserStore) writeLogged(write func(sqliteExecer, *User) error, user *User, event *ScoreEvent) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = write(tx, user)
+	if err == nil {
+		err = appendScoreEvent(tx, event)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (store *SQLiteUserStore) Delete(user *User) error {
+	_, err := store.db.Exec("DELETE FROM users WHERE uuid = ?", user.uuid)
+	return err
+}
+
+func (store *SQLiteUserStore) Get(uuid uint32) (*User, error) {
+	row := store.db.QueryRow("SELECT "+userColumns+" FROM users WHERE uuid = ?", uuid)
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return user, err
+}
+
+func (store *SQLiteUserStore) GetByLogin(login string) (*User, error) {
+	row := store.db.QueryRow("SELECT "+userColumns+" FROM users WHERE login = ?", login)
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return user, err
+}
+
+func (store *SQLiteUserStore) All() ([]User, error) {
+	rows, err := store.db.Query("SELECT " + userColumns + " FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userSlice := make([]User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		userSlice = append(userSlice, *user)
+	}
+	return userSlice, rows.Err()
+}
+
+func (store *SQLiteUserStore) Count() (int, error) {
+	count := 0
+	err := store.db.QueryRow("SELECT count(*) FROM users").Scan(&count)
+	return count, err
+}
+
+// SQLiteSessionStore is a SessionStore persisted in sqlite database.
+// Session users are resolved through users store on load.
+type SQLiteSessionStore struct {
+	db    *sql.DB
+	users UserStore
+}
+
+func NewSQLiteSessionStore(db *sql.DB, users UserStore) *SQLiteSessionStore {
+	return &SQLiteSessionStore{db: db, users: users}
+}
+
+func (store *SQLiteSessionStore) Save(session *Session) error {
+	var userUUID interface{}
+	if session.user != nil {
+		userUUID = session.user.uuid
+	}
+	_, err := store.db.Exec("INSERT OR REPLACE INTO sessions ("+sessionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		session.sid, session.id, userUUID, session.userAgent, session.csrfToken,
+		session.created.UnixNano(), session.lastSeen.UnixNano())
+	return err
+}
+
+func (store *SQLiteSessionStore) Touch(session *Session) error {
+	_, err := store.db.Exec("UPDATE sessions SET last_seen = ?, user_agent = ? WHERE sid = ?",
+		session.lastSeen.UnixNano(), session.userAgent, session.sid)
+	return err
+}
+
+func (store *SQLiteSessionStore) Delete(session *Session) error {
+	_, err := store.db.Exec("DELETE FROM sessions WHERE sid = ?", session.sid)
+	return err
+}
+
+func (store *SQLiteSessionStore) Get(sid string) (*Session, error) {
+	row := store.db.QueryRow("SELECT "+sessionColumns+" FROM sessions WHERE sid = ?", sid)
+	session, userUUID, err := scanSession(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return session, store.resolveUser(session, userUUID)
+}
+
+func (store *SQLiteSessionStore) ListByUser(uuid uint32) ([]Session, error) {
+	// the same sessions Session.Expired tells apart
+	now := timeNow()
+	rows, err := store.db.Query("SELECT "+sessionColumns+" FROM sessions WHERE user_uuid = ? AND created > ? AND last_seen > ?",
+		uuid, now.Add(-sessionConfig.MaxAge).UnixNano(), now.Add(-sessionConfig.IdleTimeout).UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessionSlice := make([]Session, 0)
+	userUUIDs := make([]sql.NullInt64, 0)
+	for rows.Next() {
+		session, userUUID, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessionSlice = append(sessionSlice, *session)
+		userUUIDs = append(userUUIDs, userUUID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	// users are loaded after rows are closed, the only connection is busy until then
+	rows.Close()
+
+	for i := range sessionSlice {
+		err = store.resolveUser(&sessionSlice[i], userUUIDs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sessionSlice, nil
+}
+
+func (store *SQLiteSessionStore) DeleteExpired(createdBefore time.Time, seenBefore time.Time) (int, error) {
+	result, err := store.db.Exec("DELETE FROM sessions WHERE created < ? OR last_seen < ?",
+		createdBefore.UnixNano(), seenBefore.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// scanSession returns session without user and uuid of the user, see resolveUser
+func scanSession(row rowScanner) (*Session, sql.NullInt64, error) {
+	var userUUID sql.NullInt64
+	var created, lastSeen int64
+	session := Session{}
+	err := row.Scan(&session.sid, &session.id, &userUUID, &session.userAgent, &session.csrfToken, &created, &lastSeen)
+	if err != nil {
+		return nil, userUUID, err
+	}
+	session.created = time.Unix(0, created)
+	session.lastSeen = time.Unix(0, lastSeen)
+	return &session, userUUID, nil
+}
+
+func (store *SQLiteSessionStore) resolveUser(session *Session, userUUID sql.NullInt64) error {
+	if !userUUID.Valid {
+		return nil
+	}
+	user, err := store.users.Get(uint32(userUUID.Int64))
+	if err != nil {
+		return err
+	}
+	session.user = user
+	return nil
+}
+
+// SQLiteScoreEventStore is a ScoreEventStore persisted in sqlite database
+type SQLiteScoreEventStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteScoreEventStore(db *sql.DB) *SQLiteScoreEventStore {
+	return &SQLiteScoreEventStore{db: db}
+}
+
+func (store *SQLiteScoreEventStore) Append(event *ScoreEvent) error {
+	return appendScoreEvent(store.db, event)
+}
+
+func appendScoreEvent(db sqliteExecer, event *ScoreEvent) error {
+	result, err := db.Exec("INSERT INTO score_events (user_uuid, delta, match, at, source) VALUES (?, ?, ?, ?, ?)",
+		event.user, event.delta, event.match, event.at.UnixNano(), event.source)
+	if err != nil {
+		return err
+	}
+	event.id, err = result.LastInsertId()
+	return err
+}
+
+func (store *SQLiteScoreEventStore) ListByUser(uuid uint32, before int64, count int) ([]ScoreEvent, error) {
+	if before == 0 {
+		before = math.MaxInt64
+	}
+	return store.query("SELECT "+scoreEventColumns+" FROM score_events WHERE user_uuid = ? AND id < ? ORDER BY id DESC LIMIT ?",
+		uuid, before, count)
+}
+
+func (store *SQLiteScoreEventStore) Since(t time.Time) ([]ScoreEvent, error) {
+	return store.query("SELECT "+scoreEventColumns+" FROM score_events WHERE at >= ? ORDER BY id", t.UnixNano())
+}
+
+func (store *SQLiteScoreEventStore) query(query string, args ...interface{}) ([]ScoreEvent, error) {
+	rows, err := store.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	eventSlice := make([]ScoreEvent, 0)
+	for rows.Next() {
+		var at int64
+		event := ScoreEvent{}
+		err := rows.Scan(&event.id, &event.user, &event.delta, &event.match, &at, &event.source)
+		if err != nil {
+			return nil, err
+		}
+		event.at = time.Unix(0, at)
+		eventSlice = append(eventSlice, event)
+	}
+	return eventSlice, rows.Err()
+}
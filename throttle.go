@@ -0,0 +1,242 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ThrottleConfig describes how fast failed attempts are punished.
+// First FreeAttempts failures cost nothing, then every failure doubles
+// the wait starting from BaseDelay up to MaxDelay. After LockoutAfter
+// failures the key is locked for LockoutTime. Keys without failures
+// for ForgetAfter start from scratch. At most MaxKeys keys are
+// remembered, keys that are not blocked are dropped when it is full.
+type ThrottleConfig struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockoutAfter int
+	LockoutTime  time.Duration
+	ForgetAfter  time.Duration
+	MaxKeys      int
+}
+
+var accountThrottleConfig = ThrottleConfig{
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     time.Minute,
+	LockoutAfter: 10,
+	LockoutTime:  15 * time.Minute,
+	ForgetAfter:  time.Hour,
+	MaxKeys:      100000,
+}
+
+// many players may share an address, so it is allowed more
+var addressThrottleConfig = ThrottleConfig{
+	FreeAttempts: 20,
+	BaseDelay:    time.Second,
+	MaxDelay:     time.Minute,
+	LockoutAfter: 100,
+	LockoutTime:  15 * time.Minute,
+	ForgetAfter:  time.Hour,
+	MaxKeys:      100000,
+}
+
+type throttleEntry struct {
+	failures     int
+	pending      int // attempts allowed whose outcome is not known yet
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// Throttle counts failed attempts per key
+type Throttle struct {
+	config    ThrottleConfig
+	mu        sync.Mutex
+	entries   map[string]*throttleEntry
+	lastSweep time.Time
+}
+
+func NewThrottle(config ThrottleConfig) *Throttle {
+	return &Throttle{
+		config:  config,
+		entries: make(map[string]*throttleEntry),
+	}
+}
+
+func (throttle *Throttle) forgotten(entry *throttleEntry, now time.Time) bool {
+	return now.Sub(entry.lastFailure) >= throttle.config.ForgetAfter && !now.Before(entry.blockedUntil) &&
+		entry.pending == 0
+}
+
+// entry returns entry of key, making a new one if key is not remembered
+func (throttle *Throttle) entry(key string, now time.Time) *throttleEntry {
+	entry, ok := throttle.entries[key]
+	if ok && now.Sub(entry.lastFailure) >= throttle.config.ForgetAfter && !now.Before(entry.blockedUntil) {
+		entry.failures = 0
+	}
+	if ok {
+		return entry
+	}
+
+	throttle.sweep(now)
+	if len(throttle.entries) >= throttle.config.MaxKeys {
+		// a dropped key gets its free attempts back, a blocked one would be let go
+		for key, entry := range throttle.entries {
+			if !now.Before(entry.blockedUntil) && entry.pending == 0 {
+				delete(throttle.entries, key)
+			}
+		}
+	}
+	entry = &throttleEntry{}
+	throttle.entries[key] = entry
+	return entry
+}
+
+// Attempt returns how long key has to wait before next attempt, 0 if it may try now.
+// An allowed attempt has to end with Fail or Release. Once free attempts are used up
+// key gets one attempt at a time, so parallel requests can't skip delays.
+func (throttle *Throttle) Attempt(key string, now time.Time) time.Duration {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	entry := throttle.entry(key, now)
+	if now.Before(entry.blockedUntil) {
+		return entry.blockedUntil.Sub(now)
+	}
+	if entry.pending > 0 && entry.failures+entry.pending >= throttle.config.FreeAttempts {
+		return throttle.config.BaseDelay
+	}
+	entry.pending++
+	return 0
+}
+
+// Release ends an attempt that did not fail
+func (throttle *Throttle) Release(key string) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	if entry, ok := throttle.entries[key]; ok && entry.pending > 0 {
+		entry.pending--
+	}
+}
+
+// Fail ends an attempt that failed, next attempts of key may have to wait
+func (throttle *Throttle) Fail(key string, now time.Time) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	entry := throttle.entry(key, now)
+	if entry.pending > 0 {
+		entry.pending--
+	}
+	entry.failures++
+	entry.lastFailure = now
+
+	config := throttle.config
+	switch {
+	case entry.failures >= config.LockoutAfter:
+		entry.blockedUntil = now.Add(config.LockoutTime)
+	case entry.failures > config.FreeAttempts:
+		delay := config.MaxDelay
+		if shift := uint(entry.failures - config.FreeAttempts - 1); shift < 32 && config.BaseDelay<<shift < config.MaxDelay {
+			delay = config.BaseDelay << shift
+		}
+		entry.blockedUntil = now.Add(delay)
+	}
+}
+
+// Reset forgets failures of key, attempts of key still running are kept
+func (throttle *Throttle) Reset(key string) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	entry, ok := throttle.entries[key]
+	if !ok {
+		return
+	}
+	if entry.pending == 0 {
+		delete(throttle.entries, key)
+		return
+	}
+	*entry = throttleEntry{pending: entry.pending}
+}
+
+func (throttle *Throttle) sweep(now time.Time) {
+	if now.Sub(throttle.lastSweep) < throttle.config.ForgetAfter {
+		return
+	}
+	for key, entry := range throttle.entries {
+		if throttle.forgotten(entry, now) {
+			delete(throttle.entries, key)
+		}
+	}
+	throttle.lastSweep = now
+}
+
+// RetryLaterError is returned when attempts are throttled
+type RetryLaterError struct {
+	RetryAfter time.Duration
+}
+
+func (err *RetryLaterError) Error() string {
+	return "too many attempts"
+}
+
+// LoginThrottle limits password guessing both per account and per client address
+type LoginThrottle struct {
+	accounts  *Throttle
+	addresses *Throttle
+}
+
+func NewLoginThrottle() *LoginThrottle {
+	return &LoginThrottle{
+		accounts:  NewThrottle(accountThrottleConfig),
+		addresses: NewThrottle(addressThrottleConfig),
+	}
+}
+
+var loginThrottle = NewLoginThrottle()
+
+// Attempt returns how long login from address has to wait, 0 if it may try now.
+// An allowed attempt has to end with Fail, Succeed or Release.
+func (throttle *LoginThrottle) Attempt(login string, address string, now time.Time) time.Duration {
+	retryAfter := throttle.accounts.Attempt(login, now)
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	retryAfter = throttle.addresses.Attempt(address, now)
+	if retryAfter > 0 {
+		throttle.accounts.Release(login)
+	}
+	return retryAfter
+}
+
+func (throttle *LoginThrottle) Fail(login string, address string, now time.Time) {
+	throttle.accounts.Fail(login, now)
+	throttle.addresses.Fail(address, now)
+}
+
+// Succeed forgets failures of the account, failures of the address stay:
+// otherwise attacker could reset them by logging into own account
+func (throttle *LoginThrottle) Succeed(login string, address string) {
+	throttle.accounts.Release(login)
+	throttle.accounts.Reset(login)
+	throttle.addresses.Release(address)
+}
+
+// Release ends an attempt that neither failed nor succeeded
+func (throttle *LoginThrottle) Release(login string, address string) {
+	throttle.accounts.Release(login)
+	throttle.addresses.Release(address)
+}
+
+func remoteAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
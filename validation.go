@@ -0,0 +1,183 @@
+package main
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Rule checks a field value, it returns nil if value is fine
+type Rule func(value string, request *UsrRequest) *FieldError
+
+// FieldRules is everything that has to hold for one UsrRequest field.
+// Normalize runs before the rules and its result replaces the value.
+// Checking stops at the first broken rule, so there is one error per field.
+type FieldRules struct {
+	Field     string
+	Value     func(request *UsrRequest) *string
+	Normalize func(value string) string
+	Rules     []Rule
+}
+
+var loginPattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]*$`)
+
+var usrRequestRules = []FieldRules{
+	{
+		Field: "login",
+		Value: func(request *UsrRequest) *string { return &request.Login },
+		Rules: []Rule{
+			MinLength(3),
+			MaxLength(32),
+			Matches(loginPattern, "login may contain only latin letters, digits, '_', '-' and '.'"),
+		},
+	},
+	{
+		Field: "password",
+		Value: func(request *UsrRequest) *string { return &request.Password },
+		Rules: []Rule{
+			MinLength(8),
+			// bcrypt ignores everything after 72 bytes
+			MaxBytes(72),
+			StrongPassword,
+		},
+	},
+	{
+		Field:     "email",
+		Value:     func(request *UsrRequest) *string { return &request.Email },
+		Normalize: strings.TrimSpace,
+		Rules: []Rule{
+			MaxLength(254),
+			Email,
+		},
+	},
+	{
+		Field: "name",
+		Value: func(request *UsrRequest) *string { return &request.Name },
+		Normalize: func(value string) string {
+			return strings.TrimSpace(norm.NFC.String(value))
+		},
+		Rules: []Rule{
+			MaxLength(32),
+			Printable,
+		},
+	},
+}
+
+// ValidateUsrRequest normalizes request fields in place and checks them
+// against usrRequestRules, returning *ValidationError with every broken field.
+// Empty fields are errors only if requireAll is set, otherwise they are skipped.
+func ValidateUsrRequest(request *UsrRequest, requireAll bool) error {
+	fieldErrors := make([]FieldError, 0)
+	for _, field := range usrRequestRules {
+		value := field.Value(request)
+		if *value == "" && !requireAll {
+			continue
+		}
+		if field.Normalize != nil {
+			*value = field.Normalize(*value)
+		}
+		if *value == "" {
+			fieldErrors = append(fieldErrors, FieldError{field.Field, "required", "missing " + field.Field})
+			continue
+		}
+
+		for _, rule := range field.Rules {
+			if err := rule(*value, request); err != nil {
+				err.Field = field.Field
+				fieldErrors = append(fieldErrors, *err)
+				break
+			}
+		}
+	}
+
+	if len(fieldErrors) != 0 {
+		return &ValidationError{fieldErrors}
+	}
+	return nil
+}
+
+func MinLength(min int) Rule {
+	return func(value string, request *UsrRequest) *FieldError {
+		if utf8.RuneCountInString(value) < min {
+			return &FieldError{Code: "too_short", Message: "too short"}
+		}
+		return nil
+	}
+}
+
+func MaxLength(max int) Rule {
+	return func(value string, request *UsrRequest) *FieldError {
+		if utf8.RuneCountInString(value) > max {
+			return &FieldError{Code: "too_long", Message: "too long"}
+		}
+		return nil
+	}
+}
+
+func MaxBytes(max int) Rule {
+	return func(value string, request *UsrRequest) *FieldError {
+		if len(value) > max {
+			return &FieldError{Code: "too_long", Message: "too long"}
+		}
+		return nil
+	}
+}
+
+func Matches(pattern *regexp.Regexp, message string) Rule {
+	return func(value string, request *UsrRequest) *FieldError {
+		if !pattern.MatchString(value) {
+			return &FieldError{Code: "pattern", Message: message}
+		}
+		return nil
+	}
+}
+
+func Email(value string, request *UsrRequest) *FieldError {
+	address, err := mail.ParseAddress(value)
+	// display names like "Bob <bob@mail.ru>" are not emails
+	if err != nil || address.Address != value {
+		return &FieldError{Code: "email", Message: "invalid email"}
+	}
+	return nil
+}
+
+func Printable(value string, request *UsrRequest) *FieldError {
+	for _, r := range value {
+		if !unicode.IsPrint(r) {
+			return &FieldError{Code: "invalid_chars", Message: "invisible characters are not allowed"}
+		}
+	}
+	return nil
+}
+
+// StrongPassword wants at least two of lower case letters, upper case letters,
+// digits and other symbols, and password must differ from login
+func StrongPassword(value string, request *UsrRequest) *FieldError {
+	var lower, upper, digit, other int
+	for _, r := range value {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	if lower+upper+digit+other < 2 || strings.EqualFold(value, request.Login) {
+		return &FieldError{Code: "weak_password", Message: "password is too weak"}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait      = 10 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsPingPeriod     = wsPongWait * 9 / 10
+	wsMaxMessageSize = 4096
+	// wsSendBuffer is how many messages may wait for a slow client, it is dropped then
+	wsSendBuffer = 64
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin lets in pages of the same host and of allowedOrigins,
+// so that other sites can't open a socket with user cookie
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	originURL, err := url.Parse(origin)
+	return err == nil && originURL.Host == r.Host
+}
+
+// Client is a websocket connection of a user.
+// Messages are written by its own goroutine, Send never blocks.
+type Client struct {
+	conn      *websocket.Conn
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewClient(conn *websocket.Conn) *Client {
+	return &Client{
+		conn: conn,
+		send: make(chan []byte, wsSendBuffer),
+		done: make(chan struct{}),
+	}
+}
+
+// Send queues message, client that can't keep up is closed
+func (client *Client) Send(message []byte) {
+	select {
+	case <-client.done:
+	case client.send <- message:
+	default:
+		client.Close()
+	}
+}
+
+func (client *Client) Close() {
+	client.closeOnce.Do(func() { close(client.done) })
+}
+
+func (client *Client) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
+
+	for {
+		select {
+		case message := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				client.Close()
+				return
+			}
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				client.Close()
+				return
+			}
+		case <-client.done:
+			client.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteWait))
+			return
+		}
+	}
+}
+
+// readLoop passes requests of user to hub until connection breaks
+func (client *Client) readLoop(hub *Hub, uuid uint32) {
+	defer func() {
+		hub.Disconnect(uuid, client)
+		client.Close()
+	}()
+
+	client.conn.SetReadLimit(wsMaxMessageSize)
+	client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, message, err := client.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		request := &WSRequest{}
+		err = request.UnmarshalJSON(message)
+		if err != nil {
+			_, response := errorResponse("", badRequest(err))
+			reply, _ := response.MarshalJSON()
+			client.Send(reply)
+			continue
+		}
+		hub.Handle(uuid, request)
+	}
+}
+
+// HandleWebSocket upgrades request to websocket, the user is then
+// a hub player. Upgrade failures are answered by upgrader.
+func HandleWebSocket(w http.ResponseWriter, r *http.Request, session *Session) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	client := NewClient(conn)
+	hub.Connect(session.user, client)
+	go client.writeLoop()
+	go client.readLoop(hub, session.user.uuid)
+}